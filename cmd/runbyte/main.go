@@ -4,7 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,8 +14,12 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/yousuf/runbyte/internal/bundler"
 	"github.com/yousuf/runbyte/internal/config"
+	"github.com/yousuf/runbyte/internal/logging"
+	"github.com/yousuf/runbyte/internal/metrics"
 	"github.com/yousuf/runbyte/internal/server"
 	"github.com/yousuf/runbyte/internal/session"
+	"github.com/yousuf/runbyte/internal/telemetry"
+	"github.com/yousuf/runbyte/internal/typecheck"
 	"github.com/yousuf/runbyte/pkg/wasm"
 )
 
@@ -38,7 +42,7 @@ func getWasmBytes(cfg *config.Config) ([]byte, error) {
 }
 
 func runStdioServer(wasmBytes []byte, sessionMgr *session.Manager) {
-	log.Println("Runbyte server running in stdio mode")
+	slog.Info("Runbyte server running in stdio mode")
 
 	// Create MCP server
 	mcpServer := server.NewMcpServer(wasmBytes, sessionMgr)
@@ -61,22 +65,22 @@ func runStdioServer(wasmBytes []byte, sessionMgr *session.Manager) {
 	// Wait for either completion or interrupt
 	select {
 	case <-sigChan:
-		log.Println("Interrupt received, shutting down...")
+		slog.Info("Interrupt received, shutting down")
 		cancel()
 		// Wait for server to stop
 		<-errChan
 	case err := <-errChan:
 		if err != nil {
-			log.Printf("Server stopped with error: %v", err)
+			slog.Error("Server stopped with error", "error", err)
 		}
 	}
 
 	// Close all sessions
 	if err := sessionMgr.CloseAll(); err != nil {
-		log.Printf("Error closing sessions: %v", err)
+		slog.Error("Error closing sessions", "error", err)
 	}
 
-	log.Println("Server stopped")
+	slog.Info("Server stopped")
 }
 
 func runHttpServer(cfg *config.Config, wasmBytes []byte, sessionMgr *session.Manager, port int) {
@@ -93,21 +97,28 @@ func runHttpServer(cfg *config.Config, wasmBytes []byte, sessionMgr *session.Man
 		SessionTimeout: 0,
 	})
 
+	// Serve Prometheus metrics alongside MCP unless they have their own port
+	mux := http.NewServeMux()
+	mux.Handle("/", handler)
+	if metricsCfg := cfg.GetMetrics(); metricsCfg.Enabled && metricsCfg.Port == 0 {
+		mux.Handle("/metrics", metrics.Handler())
+	}
+
 	// Setup HTTP server
 	timeout := time.Duration(cfg.GetServerTimeout()) * time.Second
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf(":%d", port),
-		Handler:      handler,
+		Handler:      mux,
 		ReadTimeout:  timeout,
-		WriteTimeout: timeout,
+		WriteTimeout: time.Duration(cfg.GetServerWriteTimeout()) * time.Second,
 		IdleTimeout:  timeout * 4,
 	}
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Runbyte server listening on port %d", port)
+		slog.Info("Runbyte server listening", "port", port)
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+			fatal("Server failed", "error", err)
 		}
 	}()
 
@@ -116,22 +127,49 @@ func runHttpServer(cfg *config.Config, wasmBytes []byte, sessionMgr *session.Man
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	<-sigChan
 
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server")
 
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	if err := httpServer.Shutdown(ctx); err != nil {
-		log.Printf("Server shutdown error: %v", err)
+		slog.Error("Server shutdown error", "error", err)
 	}
 
 	// Close all sessions
 	if err := sessionMgr.CloseAll(); err != nil {
-		log.Printf("Error closing sessions: %v", err)
+		slog.Error("Error closing sessions", "error", err)
+	}
+
+	slog.Info("Server stopped")
+}
+
+// startMetricsServer serves Prometheus metrics on a separate admin port
+func startMetricsServer(port int) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	metricsServer := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
 	}
 
-	log.Println("Server stopped")
+	go func() {
+		slog.Info("Metrics listening", "port", port)
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("Metrics server failed", "error", err)
+		}
+	}()
+
+	return metricsServer
+}
+
+// fatal logs an error and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
 
 func main() {
@@ -156,24 +194,66 @@ func main() {
 		AllowEnvOverrides: true,
 	})
 	if err != nil {
-		log.Fatalf("Failed to load config: %v\n\nHint: Specify a config file with -config flag or RUNBYTE_CONFIG env var", err)
+		fatal("Failed to load config", "error", err,
+			"hint", "Specify a config file with -config flag or RUNBYTE_CONFIG env var")
+	}
+
+	// Configure logging; records go to stderr or a file, never to stdout
+	logFile, err := logging.Setup(cfg.GetLogging())
+	if err != nil {
+		fatal("Failed to set up logging", "error", err)
 	}
+	defer logFile.Close()
 
-	log.Printf("Loaded configuration with %d MCP server(s)", len(cfg.McpServers))
+	slog.Info("Loaded configuration", "servers", len(cfg.McpServers))
 
 	// Initialize bundler
 	if err = bundler.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize bundler: %v\n\nHint: Install rspack with: npm install -g @rspack/cli @rspack/core", err)
+		fatal("Failed to initialize bundler", "error", err,
+			"hint", "Install rspack with: npm install -g @rspack/cli @rspack/core")
+	}
+	slog.Info("Bundler initialized successfully")
+
+	// Initialize type checker (optional - execution proceeds without it)
+	if cfg.GetTypeCheck() {
+		if err = typecheck.Initialize(); err != nil {
+			slog.Warn("Type checking disabled", "error", err,
+				"hint", "Install TypeScript with: npm install -g typescript")
+		} else {
+			slog.Info("Type checker initialized successfully")
+		}
+	}
+
+	// Initialize tracing (optional - disabled without an exporter)
+	if telemetryCfg := cfg.GetTelemetry(); telemetryCfg.Exporter != "" {
+		if err = telemetry.Initialize(telemetryCfg, *transportMode == "stdio"); err != nil {
+			fatal("Failed to initialize telemetry", "error", err)
+		}
+		slog.Info("Tracing enabled", "exporter", telemetryCfg.Exporter)
 	}
-	log.Println("Bundler initialized successfully")
 
 	// Create session manager
 	sessionMgr := session.NewManager(cfg)
 
+	// Notify resource subscribers of file changes, through whichever server handles each session
+	server.NotifyResourceUpdates(sessionMgr)
+
+	// Expose Prometheus metrics (optional)
+	var metricsServer *http.Server
+	if metricsCfg := cfg.GetMetrics(); metricsCfg.Enabled {
+		metrics.OnScrape(sessionMgr.UpdateMetrics)
+		switch {
+		case metricsCfg.Port > 0:
+			metricsServer = startMetricsServer(metricsCfg.Port)
+		case *transportMode == "stdio":
+			slog.Warn("Metrics disabled: server.metrics.port is required in stdio mode")
+		}
+	}
+
 	// Load WASM bytes (embedded or from config)
 	wasmBytes, err := getWasmBytes(cfg)
 	if err != nil {
-		log.Fatalf("Failed to load WASM: %v", err)
+		fatal("Failed to load WASM", "error", err)
 	}
 
 	// Route to appropriate transport mode
@@ -193,6 +273,17 @@ func main() {
 		}
 		runHttpServer(cfg, wasmBytes, sessionMgr, port)
 	default:
-		log.Fatalf("Invalid transport mode: %s (must be 'stdio' or 'http')", *transportMode)
+		fatal("Invalid transport mode (must be 'stdio' or 'http')", "transport", *transportMode)
+	}
+
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
+
+	// Export spans still queued
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := telemetry.Shutdown(ctx); err != nil {
+		slog.Error("Error shutting down telemetry", "error", err)
 	}
 }
@@ -43,7 +43,8 @@ export default {
     resolve: {
         extensions: [".ts"],
         alias: {
-            '@runbyte/fs': './builtin/@runbyte/fs/index.ts'
+            '@runbyte/fs': './builtin/@runbyte/fs/index.ts',
+            '@runbyte/kv': './builtin/@runbyte/kv/index.ts'
         }
     }
 };
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yousuf/runbyte/internal/config"
+)
+
+// ResultCache caches results of read-only and idempotent tool calls, evicting the
+// least recently used entries beyond its limits. It is safe for concurrent use.
+type ResultCache struct {
+	ttl          time.Duration
+	maxEntries   int
+	maxEntrySize int64
+	maxTotalSize int64
+	tools        map[string]bool // Additional cacheable tools as "server.tool" or "server.*"
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // Front is the most recently used entry
+	size    int64
+}
+
+// cacheEntry is a cached tool result
+type cacheEntry struct {
+	key     string
+	result  *mcp.CallToolResult
+	size    int64
+	expires time.Time
+}
+
+// NewResultCache creates a cache with the configured limits
+func NewResultCache(cfg config.CacheConfig) *ResultCache {
+	tools := make(map[string]bool, len(cfg.Tools))
+	for _, tool := range cfg.Tools {
+		tools[tool] = true
+	}
+
+	return &ResultCache{
+		ttl:          time.Duration(cfg.TTL) * time.Second,
+		maxEntries:   cfg.MaxEntries,
+		maxEntrySize: cfg.MaxEntrySize,
+		maxTotalSize: cfg.MaxTotalSize,
+		tools:        tools,
+		entries:      make(map[string]*list.Element),
+		lru:          list.New(),
+	}
+}
+
+// cacheable reports whether results of a tool may be cached
+func (c *ResultCache) cacheable(server string, tool *mcp.Tool) bool {
+	if c.tools[server+".*"] || (tool != nil && c.tools[server+"."+tool.Name]) {
+		return true
+	}
+	return tool != nil && tool.Annotations != nil && (tool.Annotations.ReadOnlyHint || tool.Annotations.IdempotentHint)
+}
+
+// get returns an unexpired cached result
+func (c *ResultCache) get(key string) (*mcp.CallToolResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+	return entry.result, true
+}
+
+// put caches a successful result, unless it exceeds the entry size limit
+func (c *ResultCache) put(key string, result *mcp.CallToolResult) {
+	data, err := json.Marshal(result)
+	if err != nil || int64(len(data)) > c.maxEntrySize {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	entry := &cacheEntry{key: key, result: result, size: int64(len(data)), expires: time.Now().Add(c.ttl)}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += entry.size
+
+	for c.lru.Len() > c.maxEntries || c.size > c.maxTotalSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove deletes an entry; the caller must hold c.mu
+func (c *ResultCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// cacheKey identifies a call by server, tool and arguments. Arguments are
+// marshaled with sorted map keys, so equal arguments produce equal keys.
+func cacheKey(server, tool string, args map[string]interface{}) string {
+	data, _ := json.Marshal(args)
+	return fmt.Sprintf("%s\x00%s\x00%s", server, tool, data)
+}
+
+type noCacheKey struct{}
+
+// WithoutCache returns a context whose tool calls bypass the result cache
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// cacheBypassed reports whether ctx bypasses the result cache
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(noCacheKey{}).(bool)
+	return bypass
+}
@@ -3,12 +3,14 @@ package client
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/yousuf/runbyte/internal/config"
+	"github.com/yousuf/runbyte/internal/telemetry"
 )
 
 // McpClient wraps an MCP client connection
@@ -21,8 +23,9 @@ type McpClient struct {
 
 // NewMcpClient creates a new MCP client based on the configuration
 // onToolsChanged is an optional callback that will be invoked when the MCP server notifies of tool changes
-func NewMcpClient(ctx context.Context, name string, cfg config.McpServerConfig, onToolsChanged func(string)) (*McpClient, error) {
-	// Create MCP client options with tool change handler
+// onLogMessage is an optional callback that will be invoked with log messages sent by the MCP server
+func NewMcpClient(ctx context.Context, name string, cfg config.McpServerConfig, onToolsChanged func(string), onLogMessage func(string, *mcp.LoggingMessageParams)) (*McpClient, error) {
+	// Create MCP client options with tool change and log message handlers
 	clientOpts := &mcp.ClientOptions{}
 	if onToolsChanged != nil {
 		// Setup handler to be called when tools change
@@ -30,6 +33,12 @@ func NewMcpClient(ctx context.Context, name string, cfg config.McpServerConfig,
 			onToolsChanged(name)
 		}
 	}
+	if onLogMessage != nil {
+		// Servers send log messages once a logging level is set (see SetLoggingLevel)
+		clientOpts.LoggingMessageHandler = func(ctx context.Context, req *mcp.LoggingMessageRequest) {
+			onLogMessage(name, req.Params)
+		}
+	}
 	var transport mcp.Transport
 	var err error
 	var usedTransport string
@@ -75,7 +84,7 @@ func NewMcpClient(ctx context.Context, name string, cfg config.McpServerConfig,
 	if err != nil {
 		// If auto-detect HTTP failed, try SSE as fallback
 		if cfg.Type == "" && usedTransport == "http (auto-detected)" {
-			fmt.Printf("HTTP connection failed for %q, trying SSE fallback...\n", name)
+			slog.InfoContext(ctx, "HTTP connection failed, trying SSE fallback", "server", name, "error", err)
 			transport, err = createSSETransport(cfg)
 			if err == nil {
 				session, err = client.Connect(ctx, transport, &mcp.ClientSessionOptions{})
@@ -90,7 +99,7 @@ func NewMcpClient(ctx context.Context, name string, cfg config.McpServerConfig,
 		}
 	}
 
-	fmt.Printf("Connected to %q using %s transport\n", name, usedTransport)
+	slog.InfoContext(ctx, "Connected to MCP server", "server", name, "transport", usedTransport)
 
 	// List available tools
 	toolsResult, err := session.ListTools(ctx, &mcp.ListToolsParams{})
@@ -137,7 +146,8 @@ func createStdioTransport(cfg config.McpServerConfig) (mcp.Transport, error) {
 	return &mcp.CommandTransport{Command: cmd}, nil
 }
 
-// McpClientRoundTripper is a custom RoundTripper for injecting configured headers into MCP client requests
+// McpClientRoundTripper is a custom RoundTripper for injecting configured headers and the
+// trace context into MCP client requests
 type McpClientRoundTripper struct {
 	headers map[string]string
 	next    http.RoundTripper
@@ -148,6 +158,7 @@ func (lrt *McpClientRoundTripper) RoundTrip(req *http.Request) (*http.Response,
 	for k, v := range lrt.headers {
 		req.Header.Set(k, v)
 	}
+	telemetry.Inject(req.Context(), req.Header)
 
 	// Execute the actual request by calling the "next" RoundTripper
 	resp, err := lrt.next.RoundTrip(req)
@@ -177,12 +188,16 @@ func createSSETransport(cfg config.McpServerConfig) (mcp.Transport, error) {
 	}, nil
 }
 
-// CallTool calls a tool on this MCP client
+// CallTool calls a tool on this MCP client, passing the trace context of ctx in _meta
 func (c *McpClient) CallTool(ctx context.Context, toolName string, args map[string]interface{}) (*mcp.CallToolResult, error) {
-	return c.session.CallTool(ctx, &mcp.CallToolParams{
+	params := &mcp.CallToolParams{
 		Name:      toolName,
 		Arguments: args,
-	})
+	}
+	if traceparent := telemetry.Traceparent(ctx); traceparent != "" {
+		params.Meta = mcp.Meta{telemetry.TraceparentKey: traceparent}
+	}
+	return c.session.CallTool(ctx, params)
 }
 
 // GetTools returns the list of available tools
@@ -190,6 +205,26 @@ func (c *McpClient) GetTools() []*mcp.Tool {
 	return c.tools
 }
 
+// findTool returns the tool with the given name, or nil if the server has none
+func (c *McpClient) findTool(name string) *mcp.Tool {
+	for _, tool := range c.tools {
+		if tool.Name == name {
+			return tool
+		}
+	}
+	return nil
+}
+
+// SetLoggingLevel sets the minimum level of log messages the MCP server sends.
+// Servers that do not declare the logging capability are skipped.
+func (c *McpClient) SetLoggingLevel(ctx context.Context, level mcp.LoggingLevel) error {
+	initResult := c.session.InitializeResult()
+	if initResult == nil || initResult.Capabilities == nil || initResult.Capabilities.Logging == nil {
+		return nil
+	}
+	return c.session.SetLoggingLevel(ctx, &mcp.SetLoggingLevelParams{Level: level})
+}
+
 // GetName returns the client name
 func (c *McpClient) GetName() string {
 	return c.name
@@ -3,11 +3,13 @@ package client
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/yousuf/runbyte/internal/config"
+	"github.com/yousuf/runbyte/internal/telemetry"
 )
 
 // McpClientHub manages multiple MCP client connections with lazy tool caching.
@@ -33,20 +35,37 @@ import (
 // - When notified, McpClient calls back to ClientHub via handleToolsChanged
 // - ClientHub automatically refreshes tools and invalidates cache
 // - Optional: ClientHub can notify session layer via onToolsRefreshed callback
+// - Log messages of MCP servers are passed to the optional onLogMessage callback
 type McpClientHub struct {
 	clients          map[string]*McpClient
+	policies         map[string]*callPolicy // Call policy state per server
+	cache            *ResultCache           // Optional cache of read-only and idempotent tool results
 	mu               sync.RWMutex
 	cachedTools      map[string][]*mcp.Tool  // Lazy-cached result of Tools()
 	onToolsRefreshed func(serverName string) // Optional callback for session layer
+	logger           *slog.Logger
+
+	// onLogMessage has its own lock, as servers may send log messages while Connect holds mu
+	logMu        sync.RWMutex
+	onLogMessage func(serverName string, params *mcp.LoggingMessageParams)
 }
 
 // NewMcpClientHub creates a new McpClientHub
 func NewMcpClientHub() *McpClientHub {
 	return &McpClientHub{
-		clients: make(map[string]*McpClient),
+		clients:  make(map[string]*McpClient),
+		policies: make(map[string]*callPolicy),
+		logger:   slog.Default(),
 	}
 }
 
+// SetLogger sets the logger of background work such as tool refreshes, e.g. to tag records with the session
+func (ch *McpClientHub) SetLogger(logger *slog.Logger) {
+	ch.mu.Lock()
+	ch.logger = logger
+	ch.mu.Unlock()
+}
+
 // Connect establishes connections to all configured MCP servers
 // Each client will be set up with a callback to notify the hub when tools change
 func (ch *McpClientHub) Connect(ctx context.Context, cfg *config.Config) error {
@@ -54,8 +73,14 @@ func (ch *McpClientHub) Connect(ctx context.Context, cfg *config.Config) error {
 	defer ch.mu.Unlock()
 
 	for name, serverCfg := range cfg.McpServers {
-		// Pass callback so client can notify hub when tools change
-		client, err := NewMcpClient(ctx, name, serverCfg, ch.handleToolsChanged)
+		policy, err := newCallPolicy(name, serverCfg.GetPolicy())
+		if err != nil {
+			return fmt.Errorf("server %q: %w", name, err)
+		}
+		ch.policies[name] = policy
+
+		// Pass callbacks so client can notify hub when tools change and forward log messages
+		client, err := NewMcpClient(ctx, name, serverCfg, ch.handleToolsChanged, ch.handleLogMessage)
 		if err != nil {
 			return fmt.Errorf("failed to connect to server %q: %w", name, err)
 		}
@@ -65,17 +90,78 @@ func (ch *McpClientHub) Connect(ctx context.Context, cfg *config.Config) error {
 	return nil
 }
 
-// CallTool calls a tool on a specific MCP server
+// SetResultCache enables caching of tool results. The cache may be shared between hubs.
+func (ch *McpClientHub) SetResultCache(cache *ResultCache) {
+	ch.mu.Lock()
+	ch.cache = cache
+	ch.mu.Unlock()
+}
+
+// CallTool calls a tool on a specific MCP server, enforcing the server's call policy.
+// Results of cacheable tools are served from the result cache unless ctx bypasses it.
+// Calls are counted by the CallRecorder of ctx, if any, and traced as client spans.
 func (ch *McpClientHub) CallTool(ctx context.Context, serverName, toolName string, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	ctx, span := telemetry.Start(ctx, "tools/call "+toolName, telemetry.KindClient,
+		telemetry.Attr{Key: "mcp.method.name", Value: "tools/call"},
+		telemetry.Attr{Key: "gen_ai.tool.name", Value: toolName},
+		telemetry.Attr{Key: "runbyte.server.name", Value: serverName},
+	)
+	defer span.End()
+
 	ch.mu.RLock()
 	client, exists := ch.clients[serverName]
+	policy := ch.policies[serverName]
+	cache := ch.cache
+	var tool *mcp.Tool
+	if exists {
+		tool = client.findTool(toolName)
+	}
+	cacheable := exists && cache != nil && !cacheBypassed(ctx) && cache.cacheable(serverName, tool)
 	ch.mu.RUnlock()
 
 	if !exists {
-		return nil, fmt.Errorf("server %q not found", serverName)
+		err := fmt.Errorf("server %q not found", serverName)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	toolLabel := toolName
+	if tool == nil {
+		toolLabel = unknownTool
 	}
 
-	return client.CallTool(ctx, toolName, args)
+	recorder := callRecorder(ctx)
+	key := ""
+	if cacheable {
+		key = cacheKey(serverName, toolName, args)
+		if result, ok := cache.get(key); ok {
+			recorder.cacheHits.Add(1)
+			span.SetAttr("runbyte.cache.hit", true)
+			downstreamCalls.Inc(serverName, toolLabel, callStatusCached)
+			return result, nil
+		}
+	}
+
+	start := time.Now()
+	result, err := policy.call(ctx, recorder, func(ctx context.Context) (*mcp.CallToolResult, error) {
+		return client.CallTool(ctx, toolName, args)
+	})
+	downstreamCallSeconds.Observe(time.Since(start).Seconds(), serverName)
+	if key != "" && err == nil && !result.IsError {
+		cache.put(key, result)
+	}
+
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		downstreamCalls.Inc(serverName, toolLabel, callStatusError)
+	case result.IsError:
+		span.Fail(getTextContent(result.Content))
+		downstreamCalls.Inc(serverName, toolLabel, callStatusToolError)
+	default:
+		downstreamCalls.Inc(serverName, toolLabel, callStatusOK)
+	}
+	return result, err
 }
 
 // Servers returns a list of all connected server names
@@ -228,7 +314,11 @@ func (ch *McpClientHub) SetToolsRefreshedCallback(callback func(serverName strin
 func (ch *McpClientHub) handleToolsChanged(serverName string) {
 	// Run in goroutine to avoid blocking the notification callback
 	go func() {
-		fmt.Printf("Tools changed notification received for server %q\n", serverName)
+		ch.mu.RLock()
+		logger := ch.logger.With("server", serverName)
+		ch.mu.RUnlock()
+
+		logger.Debug("Tools changed notification received")
 
 		// Create a timeout context for the refresh operation
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -236,11 +326,11 @@ func (ch *McpClientHub) handleToolsChanged(serverName string) {
 
 		// Refresh tools from the server
 		if err := ch.RefreshServerTools(ctx, serverName); err != nil {
-			fmt.Printf("Failed to auto-refresh tools for %q: %v\n", serverName, err)
+			logger.Warn("Failed to auto-refresh tools", "error", err)
 			return
 		}
 
-		fmt.Printf("Successfully auto-refreshed tools for server %q\n", serverName)
+		logger.Info("Auto-refreshed tools")
 
 		// Notify session layer if callback is set
 		ch.mu.RLock()
@@ -253,6 +343,45 @@ func (ch *McpClientHub) handleToolsChanged(serverName string) {
 	}()
 }
 
+// SetLogMessageCallback sets an optional callback to be notified of log messages sent by MCP servers
+// This allows the session layer to forward them to its own client
+func (ch *McpClientHub) SetLogMessageCallback(callback func(serverName string, params *mcp.LoggingMessageParams)) {
+	ch.logMu.Lock()
+	ch.onLogMessage = callback
+	ch.logMu.Unlock()
+}
+
+// handleLogMessage is called by McpClient when it receives a log message notification
+func (ch *McpClientHub) handleLogMessage(serverName string, params *mcp.LoggingMessageParams) {
+	ch.logMu.RLock()
+	callback := ch.onLogMessage
+	ch.logMu.RUnlock()
+
+	if callback != nil {
+		callback(serverName, params)
+	}
+}
+
+// SetLoggingLevel sets the minimum level of log messages sent by all MCP servers
+// that support logging, e.g. to mirror the level requested by the session's client
+func (ch *McpClientHub) SetLoggingLevel(ctx context.Context, level mcp.LoggingLevel) error {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	var errs []error
+	for name, client := range ch.clients {
+		if err := client.SetLoggingLevel(ctx, level); err != nil {
+			errs = append(errs, fmt.Errorf("server %q: %w", name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to set logging level of some servers: %v", errs)
+	}
+
+	return nil
+}
+
 // Close closes all client connections
 func (ch *McpClientHub) Close() error {
 	ch.mu.Lock()
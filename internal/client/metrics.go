@@ -0,0 +1,22 @@
+package client
+
+import (
+	"github.com/yousuf/runbyte/internal/metrics"
+)
+
+// Downstream call statuses
+const (
+	callStatusOK        = "ok"
+	callStatusToolError = "tool_error" // The tool returned a result with isError set
+	callStatusError     = "error"      // The call failed, was rejected by the circuit breaker or was cancelled
+	callStatusCached    = "cached"     // Answered from the result cache
+)
+
+// unknownTool labels calls to tools the server does not list, keeping label values bounded
+const unknownTool = "unknown"
+
+// Downstream call metrics
+var (
+	downstreamCalls       = metrics.NewCounter("runbyte_downstream_calls_total", "Tool calls to downstream servers by server, tool and status (ok, tool_error, error or cached)", "server", "tool", "status")
+	downstreamCallSeconds = metrics.NewHistogram("runbyte_downstream_call_duration_seconds", "Latency of downstream tool calls by server, including throttling and retries", metrics.DurationBuckets, "server")
+)
@@ -0,0 +1,292 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yousuf/runbyte/internal/config"
+)
+
+// CallStats summarizes tool calls made under server policies
+type CallStats struct {
+	Calls       int64 `json:"calls"`       // Tool calls sent to servers, not counting retries
+	CacheHits   int64 `json:"cacheHits"`   // Tool calls answered from the result cache
+	Failures    int64 `json:"failures"`    // Calls that failed after all attempts, including rejected calls
+	Retries     int64 `json:"retries"`     // Extra attempts after transport errors or matching error patterns
+	Rejected    int64 `json:"rejected"`    // Calls rejected without an attempt because a circuit was open
+	ThrottledMs int64 `json:"throttledMs"` // Time spent waiting for rate limits and concurrency slots
+}
+
+// CallRecorder accumulates CallStats. It is safe for concurrent use.
+type CallRecorder struct {
+	calls, cacheHits, failures, retries, rejected atomic.Int64
+	throttled                                     atomic.Int64 // Nanoseconds
+}
+
+// Stats returns the recorded statistics
+func (r *CallRecorder) Stats() CallStats {
+	return CallStats{
+		Calls:       r.calls.Load(),
+		CacheHits:   r.cacheHits.Load(),
+		Failures:    r.failures.Load(),
+		Retries:     r.retries.Load(),
+		Rejected:    r.rejected.Load(),
+		ThrottledMs: time.Duration(r.throttled.Load()).Milliseconds(),
+	}
+}
+
+type callRecorderKey struct{}
+
+// WithCallRecorder returns a context whose tool calls are counted by recorder
+func WithCallRecorder(ctx context.Context, recorder *CallRecorder) context.Context {
+	return context.WithValue(ctx, callRecorderKey{}, recorder)
+}
+
+// callRecorder returns the recorder of ctx, or a throwaway one
+func callRecorder(ctx context.Context) *CallRecorder {
+	if recorder, ok := ctx.Value(callRecorderKey{}).(*CallRecorder); ok {
+		return recorder
+	}
+	return &CallRecorder{}
+}
+
+// callPolicy enforces a server's rate limit, concurrency limit, retries and circuit breaker
+type callPolicy struct {
+	server   string
+	interval time.Duration // Minimum spacing between call starts (0 = unlimited)
+	slots    chan struct{} // Concurrency semaphore (nil = unlimited)
+	retry    *config.RetryConfig
+	patterns []*regexp.Regexp
+	breaker  *config.CircuitBreakerConfig
+
+	mu        sync.Mutex
+	nextStart time.Time // Earliest start of the next call under the rate limit
+	failures  int       // Consecutive failures
+	openUntil time.Time // Circuit is open until then
+	probing   bool      // A trial call is in flight on a half-open circuit
+}
+
+// newCallPolicy creates the policy state of a server
+func newCallPolicy(server string, cfg config.CallPolicyConfig) (*callPolicy, error) {
+	p := &callPolicy{
+		server:  server,
+		retry:   cfg.Retry,
+		breaker: cfg.CircuitBreaker,
+	}
+	if cfg.MaxCallsPerSecond > 0 {
+		p.interval = time.Duration(float64(time.Second) / cfg.MaxCallsPerSecond)
+	}
+	if cfg.MaxConcurrency > 0 {
+		p.slots = make(chan struct{}, cfg.MaxConcurrency)
+	}
+	if cfg.Retry != nil {
+		for _, pattern := range cfg.Retry.ErrorPatterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid retry error pattern %q: %w", pattern, err)
+			}
+			p.patterns = append(p.patterns, re)
+		}
+	}
+	return p, nil
+}
+
+// call performs a tool call under the policy
+func (p *callPolicy) call(ctx context.Context, recorder *CallRecorder, do func(context.Context) (*mcp.CallToolResult, error)) (*mcp.CallToolResult, error) {
+	recorder.calls.Add(1)
+
+	if err := p.admit(); err != nil {
+		recorder.rejected.Add(1)
+		recorder.failures.Add(1)
+		return nil, err
+	}
+
+	attempts := 1
+	if p.retry != nil {
+		attempts = p.retry.MaxAttempts
+	}
+
+	var result *mcp.CallToolResult
+	var err error
+	for attempt := 1; ; attempt++ {
+		result, err = p.attempt(ctx, recorder, do)
+		retryable := p.retryable(ctx, result, err)
+		if !retryable || attempt >= attempts || !p.backoff(ctx, attempt) {
+			p.record(ctx, retryable)
+			break
+		}
+		recorder.retries.Add(1)
+	}
+
+	if err != nil || (result != nil && result.IsError) {
+		recorder.failures.Add(1)
+	}
+	return result, err
+}
+
+// attempt waits for the rate limit and a concurrency slot, then makes one attempt
+func (p *callPolicy) attempt(ctx context.Context, recorder *CallRecorder, do func(context.Context) (*mcp.CallToolResult, error)) (*mcp.CallToolResult, error) {
+	start := time.Now()
+	if p.slots != nil {
+		select {
+		case p.slots <- struct{}{}:
+			defer func() { <-p.slots }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err := p.throttle(ctx); err != nil {
+		return nil, err
+	}
+	recorder.throttled.Add(int64(time.Since(start)))
+
+	return do(ctx)
+}
+
+// throttle waits until the rate limit allows another call to start
+func (p *callPolicy) throttle(ctx context.Context) error {
+	if p.interval == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	start := time.Now()
+	if p.nextStart.After(start) {
+		start = p.nextStart
+	}
+	p.nextStart = start.Add(p.interval)
+	p.mu.Unlock()
+
+	return wait(ctx, time.Until(start))
+}
+
+// retryable reports whether a failed attempt should be retried: transport errors
+// always are, tool errors and error responses only when they match an error pattern
+func (p *callPolicy) retryable(ctx context.Context, result *mcp.CallToolResult, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var message string
+	switch {
+	case err != nil && !isErrorResponse(err):
+		return true
+	case err != nil:
+		message = err.Error()
+	case result.IsError:
+		message = getTextContent(result.Content)
+	default:
+		return false
+	}
+
+	for _, re := range p.patterns {
+		if re.MatchString(message) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff waits before the next attempt, with exponential backoff and jitter.
+// It returns false if the wait would run past the context deadline.
+func (p *callPolicy) backoff(ctx context.Context, attempt int) bool {
+	delay := time.Duration(p.retry.InitialBackoff) * time.Millisecond << (attempt - 1)
+	delay = min(delay, time.Duration(p.retry.MaxBackoff)*time.Millisecond)
+	delay = delay/2 + rand.N(delay/2+1)
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return false
+	}
+	return wait(ctx, delay) == nil
+}
+
+// admit checks the circuit breaker before a call. Once the cooldown of an open
+// circuit has passed, a single trial call is let through.
+func (p *callPolicy) admit() error {
+	if p.breaker == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failures < p.breaker.FailureThreshold {
+		return nil
+	}
+	if remaining := time.Until(p.openUntil); remaining > 0 || p.probing {
+		return fmt.Errorf("circuit open for server %q after %d consecutive failures; retry in %ds",
+			p.server, p.failures, int(max(remaining, 0).Round(time.Second).Seconds()))
+	}
+	p.probing = true
+	return nil
+}
+
+// record updates the circuit breaker with the outcome of a call.
+// Calls cancelled by their context say nothing about the server and are not counted.
+func (p *callPolicy) record(ctx context.Context, failed bool) {
+	if p.breaker == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.probing = false
+	if !failed && ctx.Err() != nil {
+		return
+	}
+	if !failed {
+		p.failures = 0
+		return
+	}
+	p.failures++
+	if p.failures >= p.breaker.FailureThreshold {
+		p.openUntil = time.Now().Add(time.Duration(p.breaker.Cooldown) * time.Second)
+	}
+}
+
+// isErrorResponse reports whether err is a JSON-RPC error returned by the server,
+// rather than a failure to reach it. The SDK keeps its error type internal.
+func isErrorResponse(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if reflect.TypeOf(err).String() == "*jsonrpc2.WireError" {
+			return true
+		}
+	}
+	return false
+}
+
+// wait blocks for d or until ctx is done
+func wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// getTextContent returns the first text content of a tool result
+func getTextContent(contentList []mcp.Content) string {
+	for _, content := range contentList {
+		if textContent, ok := content.(*mcp.TextContent); ok {
+			return textContent.Text
+		}
+	}
+	return ""
+}
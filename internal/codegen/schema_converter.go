@@ -222,6 +222,7 @@ func (sc *SchemaConverter) convertObject(schema map[string]interface{}, typeName
 		if desc, ok := propSchemaMap["description"].(string); ok {
 			prop.Description = desc
 		}
+		prop.Tags = schemaTags(propSchemaMap)
 
 		tsProperties = append(tsProperties, prop)
 	}
@@ -368,6 +369,44 @@ func (sc *SchemaConverter) convertIntersection(schemas []interface{}, typeName s
 	}, nil
 }
 
+// schemaTags converts JSON Schema annotation and validation keywords into JSDoc tags
+func schemaTags(schema map[string]interface{}) []JSDocTag {
+	var tags []JSDocTag
+
+	if deprecated, ok := schema["deprecated"].(bool); ok && deprecated {
+		tags = append(tags, JSDocTag{Name: "deprecated"})
+	}
+
+	if format, ok := schema["format"].(string); ok && format != "" {
+		tags = append(tags, JSDocTag{Name: "format", Value: format})
+	}
+
+	for _, keyword := range []string{
+		"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum",
+		"minLength", "maxLength", "minItems", "maxItems",
+	} {
+		if n, ok := schemaNumber(schema, keyword); ok {
+			tags = append(tags, JSDocTag{Name: keyword, Value: formatNumber(n)})
+		}
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok && pattern != "" {
+		tags = append(tags, JSDocTag{Name: "pattern", Value: pattern})
+	}
+
+	if def, ok := schema["default"]; ok {
+		tags = append(tags, JSDocTag{Name: "default", Value: jsLiteral(def)})
+	}
+
+	if examples, ok := schema["examples"].([]interface{}); ok {
+		for _, example := range examples {
+			tags = append(tags, JSDocTag{Name: "example", Value: jsLiteral(example)})
+		}
+	}
+
+	return tags
+}
+
 // typeToString converts a TSType to its string representation
 func (sc *SchemaConverter) typeToString(t *TSType) string {
 	if t == nil {
@@ -14,27 +14,51 @@ type TSType struct {
 
 // TSProperty represents a property in a TypeScript interface
 type TSProperty struct {
-	Name        string  // Property name
-	Type        *TSType // Property type
-	IsOptional  bool    // Whether property is optional
-	Description string  // JSDoc comment
+	Name        string     // Property name
+	Type        *TSType    // Property type
+	IsOptional  bool       // Whether property is optional
+	Description string     // JSDoc comment
+	Tags        []JSDocTag // JSDoc tags derived from schema constraints
+}
+
+// JSDocTag represents a JSDoc block tag such as @minimum or @default
+type JSDocTag struct {
+	Name  string // Tag name without the leading '@'
+	Value string // Tag value
 }
 
 // TSFunction represents a generated TypeScript function
 type TSFunction struct {
-	Name         string // Function name (camelCase)
-	Description  string // JSDoc comment
-	ServerName   string // MCP server name
-	ToolName     string // Original tool name
-	ArgsTypeName string // TypeScript args interface name (or "" if no args)
-	ReturnType   string // TypeScript return type
-	HasArgs      bool   // Whether function takes arguments
+	Name          string // Function name (camelCase)
+	Description   string // JSDoc comment
+	ServerName    string // MCP server name
+	ToolName      string // Original tool name
+	ArgsTypeName  string // TypeScript args interface name (or "" if no args)
+	ReturnType    string // TypeScript return type
+	HasArgs       bool   // Whether function takes arguments
+	ValidatorName string // Runtime args validator name (or "" if validators are disabled)
+
+	Title           string       // Human-readable tool title
+	Annotations     []string     // Behaviour hints (readOnly, destructive, idempotent, openWorld)
+	Params          []TSProperty // Top-level argument properties documented as @param tags
+	Example         string       // Usage example rendered as an @example tag
+	HasOutputSchema bool         // Whether the tool declares an output schema
+}
+
+// TSValidator represents a runtime validator generated from a tool's input schema
+type TSValidator struct {
+	Name         string                 // Validator function name
+	ServerName   string                 // MCP server name
+	ToolName     string                 // Original tool name
+	ArgsTypeName string                 // TypeScript args interface name
+	Schema       map[string]interface{} // Input schema to validate against
 }
 
 // TSFile represents a complete TypeScript file to be generated
 type TSFile struct {
-	ServerName string        // Name of the MCP server
-	Imports    []string      // Import statements
-	Interfaces []*TSType     // Type/interface definitions
-	Functions  []*TSFunction // Function definitions
+	ServerName string         // Name of the MCP server
+	Imports    []string       // Import statements
+	Interfaces []*TSType      // Type/interface definitions
+	Validators []*TSValidator // Runtime validator definitions
+	Functions  []*TSFunction  // Function definitions
 }
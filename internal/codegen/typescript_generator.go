@@ -2,6 +2,7 @@ package codegen
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -11,12 +12,26 @@ import (
 // TypeScriptGenerator generates TypeScript files from tool definitions
 type TypeScriptGenerator struct {
 	converter *SchemaConverter
+	opts      GeneratorOptions
 }
 
-// NewTypeScriptGenerator creates a new TypeScript generator
+// GeneratorOptions configures what the TypeScript generator emits
+type GeneratorOptions struct {
+	// Validators emits a runtime validator per tool that checks args against the
+	// input schema and applies default values before calling the tool
+	Validators bool
+}
+
+// NewTypeScriptGenerator creates a new TypeScript generator with default options
 func NewTypeScriptGenerator() *TypeScriptGenerator {
+	return NewTypeScriptGeneratorWithOptions(GeneratorOptions{})
+}
+
+// NewTypeScriptGeneratorWithOptions creates a new TypeScript generator with the given options
+func NewTypeScriptGeneratorWithOptions(opts GeneratorOptions) *TypeScriptGenerator {
 	return &TypeScriptGenerator{
 		converter: NewSchemaConverter(),
+		opts:      opts,
 	}
 }
 
@@ -38,21 +53,42 @@ func (g *TypeScriptGenerator) GenerateFunctionFile(serverName string, tool *mcp.
 
 	// Generate args interface if inputSchema exists
 	argsTypeName := ""
+	validatorName := ""
+	var params []TSProperty
+	var inputSchema map[string]interface{}
 	if tool.InputSchema != nil {
-		if inputSchema, ok := tool.InputSchema.(map[string]interface{}); ok && len(inputSchema) > 0 {
+		if schema, ok := tool.InputSchema.(map[string]interface{}); ok && len(schema) > 0 {
+			inputSchema = schema
 			argsTypeName = strutil.ToPascalCase(tool.Name) + "Args"
 			argsType, err := g.converter.ConvertSchema(inputSchema, argsTypeName)
 			if err != nil {
 				return "", fmt.Errorf("failed to convert input schema for %q: %w", tool.Name, err)
 			}
 			file.Interfaces = append(file.Interfaces, argsType)
+			if argsType.Kind == "interface" {
+				params = argsType.Properties
+			}
+
+			// Generate runtime validator from the same schema
+			if g.opts.Validators {
+				validatorName = "validate" + argsTypeName
+				file.Validators = append(file.Validators, &TSValidator{
+					Name:         validatorName,
+					ServerName:   serverName,
+					ToolName:     tool.Name,
+					ArgsTypeName: argsTypeName,
+					Schema:       inputSchema,
+				})
+			}
 		}
 	}
 
 	// Generate result interface if outputSchema exists
 	returnType := strutil.ToPascalCase(tool.Name) + "Result"
+	hasOutputSchema := false
 	if tool.OutputSchema != nil {
 		if outputSchema, ok := tool.OutputSchema.(map[string]interface{}); ok && len(outputSchema) > 0 {
+			hasOutputSchema = true
 			resultType, err := g.converter.ConvertSchema(outputSchema, returnType)
 			if err != nil {
 				return "", fmt.Errorf("failed to convert output schema for %q: %w", tool.Name, err)
@@ -81,13 +117,20 @@ func (g *TypeScriptGenerator) GenerateFunctionFile(serverName string, tool *mcp.
 
 	// Generate function
 	function := &TSFunction{
-		Name:         strutil.ToCamelCase(tool.Name),
-		Description:  tool.Description,
-		ServerName:   serverName,
-		ToolName:     tool.Name,
-		ArgsTypeName: argsTypeName,
-		ReturnType:   returnType,
-		HasArgs:      argsTypeName != "",
+		Name:          strutil.ToCamelCase(tool.Name),
+		Description:   tool.Description,
+		ServerName:    serverName,
+		ToolName:      tool.Name,
+		ArgsTypeName:  argsTypeName,
+		ReturnType:    returnType,
+		HasArgs:       argsTypeName != "",
+		ValidatorName: validatorName,
+
+		Title:           ToolTitle(tool),
+		Annotations:     ToolAnnotationLabels(tool),
+		Params:          params,
+		Example:         buildUsageExample(serverName, tool.Name, inputSchema),
+		HasOutputSchema: hasOutputSchema,
 	}
 	file.Functions = append(file.Functions, function)
 
@@ -195,6 +238,12 @@ func (g *TypeScriptGenerator) renderFile(file *TSFile) string {
 		sb.WriteString("\n")
 	}
 
+	// Validators
+	for _, v := range file.Validators {
+		sb.WriteString(renderValidator(v))
+		sb.WriteString("\n")
+	}
+
 	// Functions
 	for _, fn := range file.Functions {
 		sb.WriteString(g.renderFunction(fn))
@@ -211,7 +260,7 @@ func (g *TypeScriptGenerator) renderType(t *TSType) string {
 	// JSDoc comment
 	if t.Description != "" {
 		sb.WriteString("/**\n")
-		sb.WriteString(fmt.Sprintf(" * %s\n", sanitizeComment(t.Description)))
+		writeCommentText(&sb, "", t.Description)
 		sb.WriteString(" */\n")
 	}
 
@@ -219,9 +268,7 @@ func (g *TypeScriptGenerator) renderType(t *TSType) string {
 	case "interface":
 		sb.WriteString(fmt.Sprintf("export interface %s {\n", t.Name))
 		for _, prop := range t.Properties {
-			if prop.Description != "" {
-				sb.WriteString(fmt.Sprintf("  /** %s */\n", sanitizeComment(prop.Description)))
-			}
+			sb.WriteString(renderPropertyComment(prop))
 			optional := ""
 			if prop.IsOptional {
 				optional = "?"
@@ -244,26 +291,86 @@ func (g *TypeScriptGenerator) renderType(t *TSType) string {
 	return sb.String()
 }
 
+// renderPropertyComment renders the JSDoc comment for an interface property.
+// Properties with a single-line description and no tags keep the compact form.
+func renderPropertyComment(prop TSProperty) string {
+	if len(prop.Tags) == 0 && !strings.Contains(prop.Description, "\n") {
+		if prop.Description == "" {
+			return ""
+		}
+		return fmt.Sprintf("  /** %s */\n", sanitizeComment(prop.Description))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("  /**\n")
+	if prop.Description != "" {
+		writeCommentText(&sb, "  ", prop.Description)
+	}
+	for _, tag := range prop.Tags {
+		sb.WriteString(fmt.Sprintf("   * %s\n", renderTag(tag)))
+	}
+	sb.WriteString("   */\n")
+	return sb.String()
+}
+
 // renderFunction renders a TypeScript function
 func (g *TypeScriptGenerator) renderFunction(fn *TSFunction) string {
 	var sb strings.Builder
 
 	// JSDoc comment
 	sb.WriteString("/**\n")
+	if fn.Title != "" {
+		writeCommentText(&sb, "", fn.Title)
+		sb.WriteString(" *\n")
+	}
 	if fn.Description != "" {
-		sb.WriteString(fmt.Sprintf(" * %s\n", sanitizeComment(fn.Description)))
+		writeCommentText(&sb, "", fn.Description)
 	} else {
-		sb.WriteString(fmt.Sprintf(" * Call tool: %s\n", fn.ToolName))
+		sb.WriteString(fmt.Sprintf(" * Call tool: %s\n", sanitizeComment(fn.ToolName)))
 	}
+	sb.WriteString(" *\n")
 
-	sb.WriteString(" * \n")
-	sb.WriteString(" * Returns parsed response - structure depends on tool implementation.\n")
+	// Behaviour hints from tool annotations
+	for _, annotation := range fn.Annotations {
+		sb.WriteString(fmt.Sprintf(" * @%s\n", annotation))
+	}
+
+	// Arguments
+	if fn.HasArgs {
+		sb.WriteString(" * @param args - Tool arguments\n")
+		for _, param := range fn.Params {
+			name := "args." + param.Name
+			if param.IsOptional {
+				name = "[" + name + "]"
+			}
+			if param.Description != "" {
+				sb.WriteString(fmt.Sprintf(" * @param %s - %s\n", name, sanitizeComment(singleLine(param.Description))))
+			} else {
+				sb.WriteString(fmt.Sprintf(" * @param %s\n", name))
+			}
+		}
+	}
+
+	sb.WriteString(" * @param [options] - Call options, e.g. { cache: false } to bypass the result cache\n")
+
+	// Return value
+	if fn.HasOutputSchema {
+		sb.WriteString(fmt.Sprintf(" * @returns Structured result matching %s\n", fn.ReturnType))
+	} else {
+		sb.WriteString(" * @returns Parsed response - structure depends on tool implementation\n")
+	}
+
+	// Usage example
+	if fn.Example != "" {
+		sb.WriteString(" * @example\n")
+		writeCommentText(&sb, "", fn.Example)
+	}
 	sb.WriteString(" */\n")
 
 	// Function signature
-	params := ""
+	params := "options?: CallOptions"
 	if fn.HasArgs {
-		params = fmt.Sprintf("args: %s", fn.ArgsTypeName)
+		params = fmt.Sprintf("args: %s, %s", fn.ArgsTypeName, params)
 	}
 
 	sb.WriteString(fmt.Sprintf("export async function %s(%s): Promise<%s> {\n",
@@ -273,8 +380,11 @@ func (g *TypeScriptGenerator) renderFunction(fn *TSFunction) string {
 	argsValue := "{}"
 	if fn.HasArgs {
 		argsValue = "args"
+		if fn.ValidatorName != "" {
+			argsValue = fmt.Sprintf("%s(args)", fn.ValidatorName)
+		}
 	}
-	sb.WriteString(fmt.Sprintf("  return await callTool(%q, %q, %s);\n",
+	sb.WriteString(fmt.Sprintf("  return await callTool(%q, %q, %s, options);\n",
 		fn.ServerName, fn.ToolName, argsValue))
 
 	sb.WriteString("}\n")
@@ -282,6 +392,54 @@ func (g *TypeScriptGenerator) renderFunction(fn *TSFunction) string {
 	return sb.String()
 }
 
+// GenerateSignature generates a compact one-line TypeScript signature for a tool function.
+// Top-level argument properties are inlined; nested types are referenced by name.
+func (g *TypeScriptGenerator) GenerateSignature(tool *mcp.Tool) string {
+	// Reset converter for each signature
+	g.converter = NewSchemaConverter()
+
+	params := ""
+	if inputSchema, ok := tool.InputSchema.(map[string]interface{}); ok && len(inputSchema) > 0 {
+		params = "args: any"
+		argsType, err := g.converter.ConvertSchema(inputSchema, strutil.ToPascalCase(tool.Name)+"Args")
+		if err == nil {
+			params = "args: " + g.inlineType(argsType)
+		}
+	}
+
+	return fmt.Sprintf("%s(%s): Promise<%s>",
+		strutil.ToCamelCase(tool.Name), params, strutil.ToPascalCase(tool.Name)+"Result")
+}
+
+// inlineType renders an interface as an inline object type, or any other type by name
+func (g *TypeScriptGenerator) inlineType(t *TSType) string {
+	if t == nil || t.Kind != "interface" {
+		return g.converter.typeToString(t)
+	}
+
+	props := make([]TSProperty, len(t.Properties))
+	copy(props, t.Properties)
+	sort.Slice(props, func(i, j int) bool {
+		if props[i].IsOptional != props[j].IsOptional {
+			return !props[i].IsOptional
+		}
+		return props[i].Name < props[j].Name
+	})
+
+	parts := make([]string, len(props))
+	for i, prop := range props {
+		optional := ""
+		if prop.IsOptional {
+			optional = "?"
+		}
+		parts[i] = fmt.Sprintf("%s%s: %s", prop.Name, optional, g.converter.typeToString(prop.Type))
+	}
+	if len(parts) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(parts, "; ") + " }"
+}
+
 // GenerateServerIndexFile generates an index.ts for a server directory that re-exports all functions
 func (g *TypeScriptGenerator) GenerateServerIndexFile(serverName string, tools []*mcp.Tool) string {
 	var sb strings.Builder
@@ -325,6 +483,174 @@ func (g *TypeScriptGenerator) GenerateIndexFile(serverNames []string) string {
 	return sb.String()
 }
 
+// ToolTitle returns the human-readable tool title, preferring the tool's own title over the annotations title
+func ToolTitle(tool *mcp.Tool) string {
+	if tool.Title != "" {
+		return tool.Title
+	}
+	if tool.Annotations != nil {
+		return tool.Annotations.Title
+	}
+	return ""
+}
+
+// ToolAnnotationLabels returns the behaviour hints a tool explicitly declares
+// (readOnly, destructive, idempotent, openWorld) in a stable order
+func ToolAnnotationLabels(tool *mcp.Tool) []string {
+	a := tool.Annotations
+	if a == nil {
+		return nil
+	}
+
+	var labels []string
+	if a.ReadOnlyHint {
+		labels = append(labels, "readOnly")
+	}
+	if a.DestructiveHint != nil && *a.DestructiveHint {
+		labels = append(labels, "destructive")
+	}
+	if a.IdempotentHint {
+		labels = append(labels, "idempotent")
+	}
+	if a.OpenWorldHint != nil && *a.OpenWorldHint {
+		labels = append(labels, "openWorld")
+	}
+	return labels
+}
+
+// buildUsageExample builds a short usage example for a tool function.
+// Arguments come from the input schema's first example, or are synthesized from required properties.
+func buildUsageExample(serverName, toolName string, inputSchema map[string]interface{}) string {
+	namespace := strutil.ToCamelCase(serverName)
+	funcName := strutil.ToCamelCase(toolName)
+
+	args := ""
+	if inputSchema != nil {
+		args = "{}"
+		if examples, ok := inputSchema["examples"].([]interface{}); ok && len(examples) > 0 {
+			if example, ok := examples[0].(map[string]interface{}); ok {
+				args = renderObjectLiteral(example, sortedKeys(example))
+			}
+		} else {
+			args = renderObjectLiteral(exampleArgs(inputSchema))
+		}
+	}
+
+	return fmt.Sprintf("import * as %s from './servers/%s';\n\nconst result = await %s.%s(%s);",
+		namespace, serverName, namespace, funcName, args)
+}
+
+// exampleArgs synthesizes example values for the required properties of an object schema
+func exampleArgs(schema map[string]interface{}) (map[string]interface{}, []string) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	required, _ := schema["required"].([]interface{})
+
+	values := make(map[string]interface{})
+	keys := make([]string, 0, len(required))
+	for _, r := range required {
+		name, ok := r.(string)
+		if !ok {
+			continue
+		}
+		propSchema, _ := properties[name].(map[string]interface{})
+		values[name] = exampleValue(name, propSchema)
+		keys = append(keys, name)
+	}
+	return values, keys
+}
+
+// exampleValue picks an example value for a property: its first example, its default,
+// its first enum value, or a placeholder for its type
+func exampleValue(name string, schema map[string]interface{}) interface{} {
+	if schema == nil {
+		return "<" + name + ">"
+	}
+	if examples, ok := schema["examples"].([]interface{}); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	if def, ok := schema["default"]; ok {
+		return def
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+
+	types := schemaTypes(schema)
+	if len(types) == 0 {
+		return "<" + name + ">"
+	}
+	switch types[0] {
+	case "number", "integer":
+		if n, ok := schemaNumber(schema, "minimum"); ok {
+			return n
+		}
+		return 1
+	case "boolean":
+		return true
+	case "array":
+		return []interface{}{}
+	case "object":
+		return map[string]interface{}{}
+	default:
+		return "<" + name + ">"
+	}
+}
+
+// renderObjectLiteral renders an object literal with the given key order
+func renderObjectLiteral(values map[string]interface{}, keys []string) string {
+	if len(keys) == 0 {
+		return "{}"
+	}
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		name := key
+		if !isIdentifier(key) {
+			name = jsLiteral(key)
+		}
+		parts[i] = fmt.Sprintf("%s: %s", name, jsLiteral(values[key]))
+	}
+	return "{ " + strings.Join(parts, ", ") + " }"
+}
+
+// isIdentifier reports whether s can be used as an unquoted object key
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		isLetter := r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !isLetter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// writeCommentText writes possibly multi-line text as JSDoc comment lines
+func writeCommentText(sb *strings.Builder, indent, text string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if line == "" {
+			sb.WriteString(indent + " *\n")
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s * %s\n", indent, sanitizeComment(line)))
+	}
+}
+
+// singleLine collapses whitespace, including newlines, into single spaces
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// renderTag renders a JSDoc tag, omitting the value for flag tags such as @deprecated
+func renderTag(tag JSDocTag) string {
+	if tag.Value == "" {
+		return "@" + tag.Name
+	}
+	return fmt.Sprintf("@%s %s", tag.Name, sanitizeComment(tag.Value))
+}
+
 // sanitizeComment escapes or removes problematic content from JSDoc comments
 func sanitizeComment(comment string) string {
 	// Replace */ with *\/ to avoid breaking JSDoc comments
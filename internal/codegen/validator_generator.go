@@ -0,0 +1,378 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formatPatterns maps JSON Schema string formats to the regular expressions used to check them.
+// Formats not listed here are accepted without checking.
+var formatPatterns = map[string]string{
+	"email":     `^[^\s@]+@[^\s@]+\.[^\s@]+$`,
+	"uri":       `^[a-zA-Z][a-zA-Z0-9+.-]*:[^\s]*$`,
+	"url":       `^[a-zA-Z][a-zA-Z0-9+.-]*:[^\s]*$`,
+	"date-time": `^\d{4}-\d{2}-\d{2}[Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})$`,
+	"date":      `^\d{4}-\d{2}-\d{2}$`,
+	"time":      `^\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})?$`,
+	"uuid":      `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`,
+	"ipv4":      `^((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)$`,
+	"hostname":  `^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`,
+}
+
+// validatorEmitter renders dependency-free JavaScript that checks a value against a JSON Schema.
+// The output only uses language built-ins so it bundles into the QuickJS sandbox as-is.
+type validatorEmitter struct {
+	sb      strings.Builder
+	counter int // Used to generate unique local variable names
+
+	name     string          // Validator function name, prefixing module-level names
+	label    string          // Tool the validator belongs to, as "server.tool"
+	patterns strings.Builder // Module-level regular expressions compiled from schema patterns
+}
+
+// renderValidator renders a validator function that clones the args, applies schema
+// defaults and throws a descriptive error on the first constraint violation
+func renderValidator(v *TSValidator) string {
+	e := &validatorEmitter{name: v.Name, label: v.ServerName + "." + v.ToolName}
+
+	e.sb.WriteString("/**\n")
+	e.sb.WriteString(fmt.Sprintf(" * Validates arguments for %s.%s and applies schema defaults.\n",
+		v.ServerName, sanitizeComment(v.ToolName)))
+	e.sb.WriteString(" * @throws Error describing the first constraint violation\n")
+	e.sb.WriteString(" */\n")
+	e.sb.WriteString(fmt.Sprintf("function %s(input: any): %s {\n", v.Name, v.ArgsTypeName))
+	e.sb.WriteString("  const args = input === undefined || input === null ? {} : JSON.parse(JSON.stringify(input));\n")
+	e.sb.WriteString("  const fail = (path: string, message: string): never => {\n")
+	e.sb.WriteString(fmt.Sprintf("    throw new Error(%s + path + \" \" + message);\n",
+		jsLiteral(fmt.Sprintf("Invalid arguments for %s.%s: ", v.ServerName, v.ToolName))))
+	e.sb.WriteString("  };\n")
+
+	e.emitSchema(v.Schema, "args", `"args"`, "  ")
+
+	e.sb.WriteString("  return args;\n")
+	e.sb.WriteString("}\n")
+
+	if e.patterns.Len() > 0 {
+		return e.patterns.String() + "\n" + e.sb.String()
+	}
+	return e.sb.String()
+}
+
+// emitSchema emits checks for value against schema.
+// pathExpr is a JavaScript expression that evaluates to the value's path for error messages.
+func (e *validatorEmitter) emitSchema(schema map[string]interface{}, value, pathExpr, indent string) {
+	if schema == nil {
+		return
+	}
+
+	// Type check
+	if types := schemaTypes(schema); len(types) > 0 {
+		checks := make([]string, len(types))
+		for i, t := range types {
+			checks[i] = typeCheck(t, value)
+			if len(types) > 1 && strings.Contains(checks[i], "&&") {
+				checks[i] = "(" + checks[i] + ")"
+			}
+		}
+		e.line(indent, "if (!(%s)) fail(%s, %s);", strings.Join(checks, " || "), pathExpr,
+			jsLiteral("must be of type "+strings.Join(types, " | ")))
+	}
+
+	// Enum check
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		values := make([]string, len(enum))
+		for i, val := range enum {
+			values[i] = fmt.Sprintf("%v", val)
+		}
+		e.line(indent, "if (!%s.includes(%s)) fail(%s, %s);", jsLiteral(enum), value, pathExpr,
+			jsLiteral("must be one of: "+strings.Join(values, ", ")))
+	}
+
+	e.emitStringChecks(schema, value, pathExpr, indent)
+	e.emitNumberChecks(schema, value, pathExpr, indent)
+	e.emitArrayChecks(schema, value, pathExpr, indent)
+	e.emitObjectChecks(schema, value, pathExpr, indent)
+}
+
+// emitStringChecks emits length, pattern and format checks
+func (e *validatorEmitter) emitStringChecks(schema map[string]interface{}, value, pathExpr, indent string) {
+	var body []string
+
+	if n, ok := schemaNumber(schema, "minLength"); ok {
+		body = append(body, fmt.Sprintf("if (%s.length < %s) fail(%s, %s);", value, formatNumber(n), pathExpr,
+			jsLiteral(fmt.Sprintf("must be at least %s characters", formatNumber(n)))))
+	}
+	if n, ok := schemaNumber(schema, "maxLength"); ok {
+		body = append(body, fmt.Sprintf("if (%s.length > %s) fail(%s, %s);", value, formatNumber(n), pathExpr,
+			jsLiteral(fmt.Sprintf("must be at most %s characters", formatNumber(n)))))
+	}
+	if pattern, ok := schema["pattern"].(string); ok && pattern != "" {
+		re := e.compilePattern(pattern)
+		body = append(body, fmt.Sprintf("if (%s && !%s.test(%s)) fail(%s, %s);", re, re, value, pathExpr,
+			jsLiteral("must match pattern "+pattern)))
+	}
+	if format, ok := schema["format"].(string); ok {
+		if pattern, known := formatPatterns[format]; known {
+			body = append(body, fmt.Sprintf("if (!new RegExp(%s).test(%s)) fail(%s, %s);", jsLiteral(pattern), value, pathExpr,
+				jsLiteral("must be a valid "+format)))
+		}
+	}
+
+	e.block(indent, fmt.Sprintf("typeof %s === \"string\"", value), body)
+}
+
+// emitNumberChecks emits minimum/maximum checks
+func (e *validatorEmitter) emitNumberChecks(schema map[string]interface{}, value, pathExpr, indent string) {
+	var body []string
+
+	bounds := []struct {
+		keyword string
+		op      string
+		message string
+	}{
+		{"minimum", "<", "must be >= %s"},
+		{"maximum", ">", "must be <= %s"},
+		{"exclusiveMinimum", "<=", "must be > %s"},
+		{"exclusiveMaximum", ">=", "must be < %s"},
+	}
+	for _, b := range bounds {
+		if n, ok := schemaNumber(schema, b.keyword); ok {
+			body = append(body, fmt.Sprintf("if (%s %s %s) fail(%s, %s);", value, b.op, formatNumber(n), pathExpr,
+				jsLiteral(fmt.Sprintf(b.message, formatNumber(n)))))
+		}
+	}
+
+	e.block(indent, fmt.Sprintf("typeof %s === \"number\"", value), body)
+}
+
+// emitArrayChecks emits item count checks and recurses into items
+func (e *validatorEmitter) emitArrayChecks(schema map[string]interface{}, value, pathExpr, indent string) {
+	minItems, hasMin := schemaNumber(schema, "minItems")
+	maxItems, hasMax := schemaNumber(schema, "maxItems")
+	items, hasItems := schema["items"].(map[string]interface{})
+	if !hasMin && !hasMax && !hasItems {
+		return
+	}
+
+	e.line(indent, "if (Array.isArray(%s)) {", value)
+	inner := indent + "  "
+	if hasMin {
+		e.line(inner, "if (%s.length < %s) fail(%s, %s);", value, formatNumber(minItems), pathExpr,
+			jsLiteral(fmt.Sprintf("must contain at least %s items", formatNumber(minItems))))
+	}
+	if hasMax {
+		e.line(inner, "if (%s.length > %s) fail(%s, %s);", value, formatNumber(maxItems), pathExpr,
+			jsLiteral(fmt.Sprintf("must contain at most %s items", formatNumber(maxItems))))
+	}
+	if hasItems {
+		index := e.nextVar("i")
+		item := e.nextVar("item")
+		e.line(inner, "for (let %s = 0; %s < %s.length; %s++) {", index, index, value, index)
+		e.line(inner+"  ", "const %s = %s[%s];", item, value, index)
+		e.emitSchema(items, item, appendPath(appendPath(pathExpr, "[")+" + "+index, "]"), inner+"  ")
+		e.line(inner, "}")
+	}
+	e.line(indent, "}")
+}
+
+// emitObjectChecks applies defaults, checks required properties and recurses into properties
+func (e *validatorEmitter) emitObjectChecks(schema map[string]interface{}, value, pathExpr, indent string) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	required, _ := schema["required"].([]interface{})
+	if len(properties) == 0 && len(required) == 0 {
+		return
+	}
+
+	e.line(indent, "if (%s) {", typeCheck("object", value))
+	inner := indent + "  "
+
+	// Apply defaults first so that defaulted properties satisfy "required"
+	for _, name := range sortedKeys(properties) {
+		propSchema, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if def, ok := propSchema["default"]; ok {
+			e.line(inner, "if (%s[%s] === undefined) %s[%s] = %s;", value, jsLiteral(name), value, jsLiteral(name), jsLiteral(def))
+		}
+	}
+
+	for _, r := range required {
+		if name, ok := r.(string); ok {
+			e.line(inner, "if (%s[%s] === undefined) fail(%s, %s);", value, jsLiteral(name),
+				propertyPath(pathExpr, name), jsLiteral("is required"))
+		}
+	}
+
+	for _, name := range sortedKeys(properties) {
+		propSchema, ok := properties[name].(map[string]interface{})
+		if !ok || !hasConstraints(propSchema) {
+			continue
+		}
+		prop := e.nextVar("p")
+		e.line(inner, "if (%s[%s] !== undefined) {", value, jsLiteral(name))
+		e.line(inner+"  ", "const %s = %s[%s];", prop, value, jsLiteral(name))
+		e.emitSchema(propSchema, prop, propertyPath(pathExpr, name), inner+"  ")
+		e.line(inner, "}")
+	}
+
+	e.line(indent, "}")
+}
+
+// line writes a single indented line
+func (e *validatorEmitter) line(indent, format string, args ...interface{}) {
+	e.sb.WriteString(indent)
+	e.sb.WriteString(fmt.Sprintf(format, args...))
+	e.sb.WriteString("\n")
+}
+
+// block writes body wrapped in an if statement, or nothing if body is empty
+func (e *validatorEmitter) block(indent, condition string, body []string) {
+	if len(body) == 0 {
+		return
+	}
+	e.line(indent, "if (%s) {", condition)
+	for _, stmt := range body {
+		e.line(indent+"  ", "%s", stmt)
+	}
+	e.line(indent, "}")
+}
+
+// compilePattern declares a module-level regular expression for a schema pattern and
+// returns its name. Patterns the runtime cannot compile are null, with a warning, so
+// the validator skips them instead of failing every call.
+func (e *validatorEmitter) compilePattern(pattern string) string {
+	name := e.nextVar(e.name + "Pattern")
+	e.patterns.WriteString(fmt.Sprintf("const %s = (() => {\n", name))
+	e.patterns.WriteString("  try {\n")
+	e.patterns.WriteString(fmt.Sprintf("    return new RegExp(%s);\n", jsLiteral(pattern)))
+	e.patterns.WriteString("  } catch (e) {\n")
+	e.patterns.WriteString(fmt.Sprintf("    console.warn(%s + e.message);\n",
+		jsLiteral(fmt.Sprintf("Not checking pattern %s of %s: ", pattern, e.label))))
+	e.patterns.WriteString("    return null;\n")
+	e.patterns.WriteString("  }\n")
+	e.patterns.WriteString("})();\n")
+	return name
+}
+
+// nextVar returns a unique local variable name with the given prefix
+func (e *validatorEmitter) nextVar(prefix string) string {
+	e.counter++
+	return fmt.Sprintf("%s%d", prefix, e.counter)
+}
+
+// typeCheck returns a JavaScript expression that checks value against a JSON Schema type
+func typeCheck(schemaType, value string) string {
+	switch schemaType {
+	case "string", "boolean", "number":
+		return fmt.Sprintf("typeof %s === %q", value, schemaType)
+	case "integer":
+		return fmt.Sprintf("Number.isInteger(%s)", value)
+	case "array":
+		return fmt.Sprintf("Array.isArray(%s)", value)
+	case "object":
+		return fmt.Sprintf("typeof %s === \"object\" && %s !== null && !Array.isArray(%s)", value, value, value)
+	case "null":
+		return fmt.Sprintf("%s === null", value)
+	default:
+		return "true"
+	}
+}
+
+// schemaTypes returns the declared types of a schema, whether given as a string or an array
+func schemaTypes(schema map[string]interface{}) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+// hasConstraints reports whether the validator would emit any checks for schema
+func hasConstraints(schema map[string]interface{}) bool {
+	for _, keyword := range []string{
+		"type", "enum", "minLength", "maxLength", "pattern", "format",
+		"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum",
+		"minItems", "maxItems", "items", "properties", "required",
+	} {
+		if _, ok := schema[keyword]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaNumber reads a numeric keyword from a schema
+func schemaNumber(schema map[string]interface{}, keyword string) (float64, bool) {
+	switch n := schema[keyword].(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// formatNumber formats a number in plain decimal notation
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// propertyPath returns a path expression for a property of the value at pathExpr
+func propertyPath(pathExpr, name string) string {
+	return appendPath(pathExpr, "."+name)
+}
+
+// appendPath appends a static suffix to a path expression, folding it into the
+// trailing string literal when there is one to keep the generated code readable
+func appendPath(pathExpr, suffix string) string {
+	idx := strings.LastIndex(pathExpr, " + ")
+	last := pathExpr[idx+len(" + "):]
+	if idx < 0 {
+		last = pathExpr
+	}
+
+	var static string
+	if strings.HasPrefix(last, `"`) && json.Unmarshal([]byte(last), &static) == nil {
+		return pathExpr[:len(pathExpr)-len(last)] + jsLiteral(static+suffix)
+	}
+	return pathExpr + " + " + jsLiteral(suffix)
+}
+
+// jsLiteral renders a value as a JavaScript literal.
+// JSON is a subset of JavaScript expression syntax, so the JSON encoding is used directly.
+func jsLiteral(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "undefined"
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// sortedKeys returns the keys of a map in sorted order for deterministic output
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -5,22 +5,236 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
 // Config represents the main configuration structure
 type Config struct {
 	Server     *ServerConfig              `json:"server,omitempty"`
+	Codegen    *CodegenConfig             `json:"codegen,omitempty"`
+	Execution  *ExecutionConfig           `json:"execution,omitempty"`
+	Search     *SearchConfig              `json:"search,omitempty"`
+	Filesystem *FilesystemConfig          `json:"filesystem,omitempty"`
+	Workspace  *WorkspaceConfig           `json:"workspace,omitempty"`
+	KV         *KVConfig                  `json:"kv,omitempty"`
+	Fetch      *FetchConfig               `json:"fetch,omitempty"`
+	Cache      *CacheConfig               `json:"cache,omitempty"`
+	Telemetry  *TelemetryConfig           `json:"telemetry,omitempty"`
+	Logging    *LoggingConfig             `json:"logging,omitempty"`
 	McpServers map[string]McpServerConfig `json:"mcpServers"`
 }
 
 // ServerConfig contains HTTP server settings
 type ServerConfig struct {
 	Port     int    `json:"port,omitempty"`
-	Timeout  int    `json:"timeout,omitempty"`  // in seconds
+	Timeout  int    `json:"timeout,omitempty"`  // HTTP read and write timeout in seconds (writes get at least the execution timeout plus headroom)
 	WasmPath string `json:"wasmPath,omitempty"` // Optional path to sandbox WASM file (defaults to embedded)
+
+	Metrics *MetricsConfig `json:"metrics,omitempty"` // Prometheus metrics endpoint
+}
+
+// MetricsConfig exposes Prometheus metrics at /metrics
+type MetricsConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	Port    int  `json:"port,omitempty"` // Separate admin port (default: the HTTP server port; required in stdio mode)
+}
+
+// CodegenConfig contains TypeScript library generation settings
+type CodegenConfig struct {
+	Validators bool `json:"validators,omitempty"` // Emit runtime argument validators with default values
+}
+
+// ExecutionConfig contains execute_code pipeline settings
+type ExecutionConfig struct {
+	Timeout            int  `json:"timeout,omitempty"`            // Deadline of an execute_code run in seconds (default 30)
+	TypeCheck          bool `json:"typeCheck,omitempty"`          // Type-check code with tsc before bundling (skipped if tsc is unavailable)
+	MaxConcurrentCalls int  `json:"maxConcurrentCalls,omitempty"` // MCP tool calls an execution runs at once (default 8)
+	ForwardConsole     bool `json:"forwardConsole,omitempty"`     // Forward console.* output of sandbox code to the client as MCP log messages
+
+	Trace *TraceConfig `json:"trace,omitempty"` // Tool call trace returned with execute_code results
+}
+
+// TraceConfig controls the tool call trace returned with execute_code results
+type TraceConfig struct {
+	Verbosity string   `json:"verbosity,omitempty"` // "off", "summary" or "full" (default: summary); execute_code can override it
+	Redact    []string `json:"redact,omitempty"`    // Additional argument names whose values are redacted
+}
+
+// Trace verbosity levels
+const (
+	TraceOff     = "off"
+	TraceSummary = "summary"
+	TraceFull    = "full"
+)
+
+// defaultTraceRedact lists argument names that are always redacted in traces.
+// Names match case-insensitively as substrings, ignoring '_' and '-'.
+var defaultTraceRedact = []string{"password", "secret", "token", "apikey", "authorization", "cookie", "credential"}
+
+// SearchConfig contains search_tools settings
+type SearchConfig struct {
+	Semantic       bool            `json:"semantic,omitempty"`       // Enable semantic and hybrid search modes
+	SemanticWeight *float64        `json:"semanticWeight,omitempty"` // Weight of the semantic score in hybrid mode (0-1, default 0.5)
+	CacheDir       string          `json:"cacheDir,omitempty"`       // Directory for cached tool vectors (defaults to the user cache dir)
+	Embedder       *EmbedderConfig `json:"embedder,omitempty"`       // Optional external embedding endpoint (defaults to local hashed n-grams)
+}
+
+// EmbedderConfig configures an external OpenAI-compatible embeddings endpoint
+type EmbedderConfig struct {
+	URL     string            `json:"url"`
+	Model   string            `json:"model,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Timeout int               `json:"timeout,omitempty"` // in seconds
+}
+
+// FilesystemConfig contains sandbox filesystem settings
+type FilesystemConfig struct {
+	Mounts []MountConfig `json:"mounts,omitempty"` // Directories exposed to sandbox code (defaults to workspace, cache and temp)
+}
+
+// MountConfig defines a directory exposed to sandbox code as /<name>
+type MountConfig struct {
+	Name         string `json:"name"`
+	Root         string `json:"root,omitempty"`         // Host directory (defaults to a directory managed by runbyte)
+	ReadOnly     bool   `json:"readOnly,omitempty"`     // Reject writes and deletes from sandbox code
+	MaxFileSize  int64  `json:"maxFileSize,omitempty"`  // in bytes (default 10MB)
+	MaxFiles     int    `json:"maxFiles,omitempty"`     // default 1000
+	MaxTotalSize int64  `json:"maxTotalSize,omitempty"` // in bytes (default 100MB)
+	Lifecycle    string `json:"lifecycle,omitempty"`    // "persistent", "session" or "execution" (default: persistent with root, otherwise session)
+	MaxVersions  int    `json:"maxVersions,omitempty"`  // Previous versions kept per file (default: 10 for the default workspace mount, otherwise 0)
+}
+
+// Mount lifecycles
+const (
+	LifecyclePersistent = "persistent" // Contents are kept when the session ends
+	LifecycleSession    = "session"    // Contents are removed when the session ends
+	LifecycleExecution  = "execution"  // Each execute_code call gets empty contents of its own
+)
+
+// WorkspaceConfig controls which sessions share the workspace mount
+type WorkspaceConfig struct {
+	Scope           string `json:"scope,omitempty"`           // "shared", "session" or "principal" (default: session)
+	BaseDir         string `json:"baseDir,omitempty"`         // Directory holding workspaces (defaults to the user cache dir)
+	PrincipalHeader string `json:"principalHeader,omitempty"` // HTTP header identifying the principal; the proxy in front of runbyte must set or strip it
+	TrustProxy      bool   `json:"trustProxy,omitempty"`      // Confirms that every request passes a proxy controlling PrincipalHeader (required with it)
+}
+
+// Workspace scopes
+const (
+	ScopeShared    = "shared"    // One workspace for all sessions, kept across restarts
+	ScopeSession   = "session"   // One workspace per session, removed when the session ends
+	ScopePrincipal = "principal" // One workspace per authenticated principal, kept across restarts
+)
+
+// Default mount limits
+const (
+	defaultMaxFileSize  = 10 * 1024 * 1024  // 10MB per file
+	defaultMaxFiles     = 1000              // files per mount
+	defaultMaxTotalSize = 100 * 1024 * 1024 // 100MB per mount
+	defaultMaxVersions  = 10                // versions per file in the default workspace
+)
+
+// KVConfig contains quotas for the @runbyte/kv store kept in each workspace
+type KVConfig struct {
+	MaxKeys      int   `json:"maxKeys,omitempty"`      // default 10000
+	MaxValueSize int64 `json:"maxValueSize,omitempty"` // in bytes (default 1MB)
+	MaxTotalSize int64 `json:"maxTotalSize,omitempty"` // in bytes (default 10MB)
+}
+
+// Default key-value store limits
+const (
+	defaultKVMaxKeys      = 10000
+	defaultKVMaxValueSize = 1024 * 1024      // 1MB per value
+	defaultKVMaxTotalSize = 10 * 1024 * 1024 // 10MB per store
+)
+
+// FetchConfig controls outbound HTTP requests made with fetch() from sandbox code.
+// fetch() is disabled unless at least one allow rule is configured.
+type FetchConfig struct {
+	Allow           []FetchRuleConfig `json:"allow,omitempty"`           // Hosts sandbox code may call
+	MaxRequestSize  int64             `json:"maxRequestSize,omitempty"`  // in bytes (default 1MB)
+	MaxResponseSize int64             `json:"maxResponseSize,omitempty"` // in bytes (default 5MB)
+	Timeout         int               `json:"timeout,omitempty"`         // in seconds per request (default 10, never beyond the execution deadline)
+	AuditLog        string            `json:"auditLog,omitempty"`        // File receiving one JSON line per request (defaults to the server log)
+}
+
+// FetchRuleConfig allows requests to a host
+type FetchRuleConfig struct {
+	Host    string            `json:"host"`              // Host name, optionally with port; "*.example.com" matches subdomains
+	Methods []string          `json:"methods,omitempty"` // Allowed HTTP methods (default: GET and HEAD)
+	Headers map[string]string `json:"headers,omitempty"` // Headers added to every request, e.g. credentials kept out of sandbox code
+}
+
+// Default fetch limits
+const (
+	defaultFetchMaxRequestSize  = 1024 * 1024     // 1MB request body
+	defaultFetchMaxResponseSize = 5 * 1024 * 1024 // 5MB response body
+	defaultFetchTimeout         = 10              // seconds
+)
+
+// CacheConfig controls caching of downstream tool results.
+// Only tools annotated readOnlyHint or idempotentHint, or listed in Tools, are cached.
+type CacheConfig struct {
+	Enabled      bool     `json:"enabled,omitempty"`
+	TTL          int      `json:"ttl,omitempty"`          // in seconds (default 60)
+	MaxEntries   int      `json:"maxEntries,omitempty"`   // default 1000
+	MaxEntrySize int64    `json:"maxEntrySize,omitempty"` // in bytes (default 1MB)
+	MaxTotalSize int64    `json:"maxTotalSize,omitempty"` // in bytes (default 50MB)
+	Scope        string   `json:"scope,omitempty"`        // "session" or "shared" (default: session)
+	Tools        []string `json:"tools,omitempty"`        // Additional cacheable tools as "server.tool" or "server.*"
+}
+
+// Cache scopes
+const (
+	CacheScopeSession = "session" // Each session has its own cache
+	CacheScopeShared  = "shared"  // All sessions share one cache
+)
+
+// Default cache limits
+const (
+	defaultCacheTTL          = 60 // seconds
+	defaultCacheMaxEntries   = 1000
+	defaultCacheMaxEntrySize = 1024 * 1024      // 1MB per result
+	defaultCacheMaxTotalSize = 50 * 1024 * 1024 // 50MB per cache
+)
+
+// LoggingConfig controls the server log. Logs never go to stdout, which carries the MCP protocol in stdio mode.
+type LoggingConfig struct {
+	Level  string `json:"level,omitempty"`  // "debug", "info", "warn" or "error" (default: info)
+	Format string `json:"format,omitempty"` // "text" or "json" (default: text)
+	File   string `json:"file,omitempty"`   // File receiving the log (defaults to stderr)
 }
 
+// Log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// TelemetryConfig exports OpenTelemetry traces of MCP requests, executions and tool calls.
+// Tracing is disabled unless an exporter is configured.
+type TelemetryConfig struct {
+	Exporter    string            `json:"exporter,omitempty"`    // "otlp", "stdout" or "file"
+	Endpoint    string            `json:"endpoint,omitempty"`    // OTLP/HTTP collector URL (default http://localhost:4318)
+	Headers     map[string]string `json:"headers,omitempty"`     // Headers sent to the collector, e.g. credentials
+	File        string            `json:"file,omitempty"`        // File receiving one JSON line per span (required for the file exporter)
+	ServiceName string            `json:"serviceName,omitempty"` // Reported service name (default "runbyte")
+}
+
+// Telemetry exporters
+const (
+	ExporterOTLP   = "otlp"   // Protobuf over HTTP to an OpenTelemetry collector
+	ExporterStdout = "stdout" // JSON lines on stdout (stderr in stdio mode)
+	ExporterFile   = "file"   // JSON lines appended to a file
+)
+
+// Default telemetry settings
+const (
+	defaultTelemetryEndpoint    = "http://localhost:4318"
+	defaultTelemetryServiceName = "runbyte"
+)
+
 // McpServerConfig is the interface for all MCP server configurations
 type McpServerConfig struct {
 	Type string `json:"type,omitempty"` // Optional: "stdio", "http", or "sse" - will be inferred if omitted
@@ -34,8 +248,42 @@ type McpServerConfig struct {
 	// HTTP/SSE fields
 	URL     string            `json:"url,omitempty"`
 	Headers map[string]string `json:"headers,omitempty"`
+
+	Policy *CallPolicyConfig `json:"policy,omitempty"` // Rate limits, retries and circuit breaking for tool calls
 }
 
+// CallPolicyConfig limits, retries and circuit-breaks tool calls to a server.
+// State is kept per session, as each session has its own server connections.
+type CallPolicyConfig struct {
+	MaxCallsPerSecond float64               `json:"maxCallsPerSecond,omitempty"` // Calls started per second (default: unlimited)
+	MaxConcurrency    int                   `json:"maxConcurrency,omitempty"`    // Calls in flight at once (default: unlimited)
+	Retry             *RetryConfig          `json:"retry,omitempty"`             // Retry failed calls with exponential backoff (default: no retries)
+	CircuitBreaker    *CircuitBreakerConfig `json:"circuitBreaker,omitempty"`    // Fail fast after consecutive failures (default: disabled)
+}
+
+// RetryConfig retries calls failing with transport errors or matching error patterns
+type RetryConfig struct {
+	MaxAttempts    int      `json:"maxAttempts,omitempty"`    // Attempts including the first (default 3)
+	InitialBackoff int      `json:"initialBackoff,omitempty"` // in milliseconds, doubled after each attempt (default 200)
+	MaxBackoff     int      `json:"maxBackoff,omitempty"`     // in milliseconds (default 5000)
+	ErrorPatterns  []string `json:"errorPatterns,omitempty"`  // Regular expressions; errors and tool error results matching one are retried
+}
+
+// CircuitBreakerConfig opens a server's circuit after consecutive failures
+type CircuitBreakerConfig struct {
+	FailureThreshold int `json:"failureThreshold,omitempty"` // Consecutive failures opening the circuit (default 5)
+	Cooldown         int `json:"cooldown,omitempty"`         // in seconds before a trial call is let through (default 30)
+}
+
+// Default call policy settings
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 200  // milliseconds
+	defaultRetryMaxBackoff     = 5000 // milliseconds
+	defaultBreakerThreshold    = 5
+	defaultBreakerCooldown     = 30 // seconds
+)
+
 // LoadOptions configures how configuration is loaded
 type LoadOptions struct {
 	// ConfigPath is the explicit path to the config file
@@ -152,6 +400,51 @@ func expandEnvVars(config *Config) {
 
 		config.McpServers[name] = server
 	}
+
+	// Expand in mount roots
+	if config.Filesystem != nil {
+		for i := range config.Filesystem.Mounts {
+			config.Filesystem.Mounts[i].Root = os.ExpandEnv(config.Filesystem.Mounts[i].Root)
+		}
+	}
+
+	// Expand in workspace base dir
+	if config.Workspace != nil {
+		config.Workspace.BaseDir = os.ExpandEnv(config.Workspace.BaseDir)
+	}
+
+	// Expand in fetch headers (typically holds API keys) and the audit log path
+	if config.Fetch != nil {
+		config.Fetch.AuditLog = os.ExpandEnv(config.Fetch.AuditLog)
+		for _, rule := range config.Fetch.Allow {
+			for key, val := range rule.Headers {
+				rule.Headers[key] = os.ExpandEnv(val)
+			}
+		}
+	}
+
+	// Expand in the log file path
+	if config.Logging != nil {
+		config.Logging.File = os.ExpandEnv(config.Logging.File)
+	}
+
+	// Expand in telemetry settings (headers typically hold collector credentials)
+	if config.Telemetry != nil {
+		config.Telemetry.Endpoint = os.ExpandEnv(config.Telemetry.Endpoint)
+		config.Telemetry.File = os.ExpandEnv(config.Telemetry.File)
+		for key, val := range config.Telemetry.Headers {
+			config.Telemetry.Headers[key] = os.ExpandEnv(val)
+		}
+	}
+
+	// Expand in embedder endpoint settings (typically holds an API key header)
+	if config.Search != nil && config.Search.Embedder != nil {
+		embedder := config.Search.Embedder
+		embedder.URL = os.ExpandEnv(embedder.URL)
+		for key, val := range embedder.Headers {
+			embedder.Headers[key] = os.ExpandEnv(val)
+		}
+	}
 }
 
 // applyEnvOverrides allows environment variables to override config values
@@ -279,6 +572,48 @@ func validate(config *Config) error {
 		return fmt.Errorf("no MCP servers configured")
 	}
 
+	if config.Search != nil && config.Search.Embedder != nil && config.Search.Embedder.URL == "" {
+		return fmt.Errorf("search.embedder: 'url' is required")
+	}
+
+	if err := validateMounts(config.Filesystem); err != nil {
+		return err
+	}
+
+	if err := validateFetch(config.Fetch); err != nil {
+		return err
+	}
+
+	if err := validateCache(config.Cache); err != nil {
+		return err
+	}
+
+	if err := validateLogging(config.Logging); err != nil {
+		return err
+	}
+
+	if err := validateTelemetry(config.Telemetry); err != nil {
+		return err
+	}
+
+	if config.Execution != nil && config.Execution.Trace != nil {
+		if err := ValidateTraceVerbosity(config.Execution.Trace.Verbosity); err != nil {
+			return fmt.Errorf("execution.trace: %w", err)
+		}
+	}
+
+	if config.Workspace != nil {
+		switch config.Workspace.Scope {
+		case "", ScopeShared, ScopeSession, ScopePrincipal:
+		default:
+			return fmt.Errorf("workspace: invalid scope %q (must be shared, session, or principal)", config.Workspace.Scope)
+		}
+		// Clients can send any header, so it only identifies principals behind a proxy that overwrites it
+		if config.Workspace.PrincipalHeader != "" && !config.Workspace.TrustProxy {
+			return fmt.Errorf("workspace: principalHeader requires trustProxy, confirming that a proxy sets or strips the header on every request")
+		}
+	}
+
 	for name, server := range config.McpServers {
 		hasCommand := server.Command != ""
 		hasURL := server.URL != ""
@@ -293,6 +628,10 @@ func validate(config *Config) error {
 			return fmt.Errorf("server %q: must specify either 'command' (for stdio) or 'url' (for http/sse)", name)
 		}
 
+		if err := validatePolicy(name, server.Policy); err != nil {
+			return err
+		}
+
 		// Validate type-specific fields
 		if server.Type != "" {
 			switch server.Type {
@@ -313,6 +652,170 @@ func validate(config *Config) error {
 	return nil
 }
 
+// validateMounts checks filesystem mount names and lifecycles
+func validateMounts(fs *FilesystemConfig) error {
+	if fs == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	roots := make(map[string]string)
+	for i, mount := range fs.Mounts {
+		if mount.Name == "" {
+			return fmt.Errorf("filesystem.mounts[%d]: 'name' is required", i)
+		}
+		if strings.ContainsAny(mount.Name, `/\`) || mount.Name == "." || mount.Name == ".." || mount.Name == "servers" {
+			return fmt.Errorf("mount %q: invalid name", mount.Name)
+		}
+		if seen[mount.Name] {
+			return fmt.Errorf("mount %q: duplicate name", mount.Name)
+		}
+		seen[mount.Name] = true
+
+		// Sessions share the directory of a root, which has one name
+		if mount.Root != "" {
+			root := filepath.Clean(mount.Root)
+			if other, ok := roots[root]; ok {
+				return fmt.Errorf("mount %q: root is already mounted as %q", mount.Name, other)
+			}
+			roots[root] = mount.Name
+		}
+
+		if mount.MaxVersions < 0 {
+			return fmt.Errorf("mount %q: maxVersions must not be negative", mount.Name)
+		}
+
+		switch mount.Lifecycle {
+		case "", LifecyclePersistent, LifecycleSession:
+		case LifecycleExecution:
+			if mount.ReadOnly {
+				return fmt.Errorf("mount %q: read-only mounts cannot use the execution lifecycle", mount.Name)
+			}
+		default:
+			return fmt.Errorf("mount %q: invalid lifecycle %q (must be persistent, session, or execution)", mount.Name, mount.Lifecycle)
+		}
+	}
+
+	return nil
+}
+
+// validateFetch checks fetch allow rules
+func validateFetch(fetch *FetchConfig) error {
+	if fetch == nil {
+		return nil
+	}
+
+	for i, rule := range fetch.Allow {
+		host := strings.TrimPrefix(rule.Host, "*.")
+		if host == "" || strings.ContainsAny(host, "/*?#@ ") {
+			return fmt.Errorf("fetch.allow[%d]: invalid host %q (use a host name such as 'api.example.com' or '*.example.com')", i, rule.Host)
+		}
+		for _, method := range rule.Methods {
+			if method == "" || strings.ToUpper(method) != method || strings.ContainsAny(method, " /") {
+				return fmt.Errorf("fetch.allow[%d]: invalid method %q (use upper-case HTTP methods such as 'GET')", i, method)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateTraceVerbosity checks a trace verbosity level; empty selects the default
+func ValidateTraceVerbosity(verbosity string) error {
+	switch verbosity {
+	case "", TraceOff, TraceSummary, TraceFull:
+		return nil
+	default:
+		return fmt.Errorf("invalid trace verbosity %q (must be off, summary, or full)", verbosity)
+	}
+}
+
+// validateCache checks the cache scope and tool names
+func validateCache(cache *CacheConfig) error {
+	if cache == nil {
+		return nil
+	}
+
+	switch cache.Scope {
+	case "", CacheScopeSession, CacheScopeShared:
+	default:
+		return fmt.Errorf("cache: invalid scope %q (must be session or shared)", cache.Scope)
+	}
+	for _, tool := range cache.Tools {
+		server, name, ok := strings.Cut(tool, ".")
+		if !ok || server == "" || name == "" {
+			return fmt.Errorf("cache.tools: invalid tool %q (use 'server.tool' or 'server.*')", tool)
+		}
+	}
+
+	return nil
+}
+
+// validateLogging checks the log level and format
+func validateLogging(logging *LoggingConfig) error {
+	if logging == nil {
+		return nil
+	}
+
+	switch strings.ToLower(logging.Level) {
+	case "", "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("logging: invalid level %q (must be debug, info, warn, or error)", logging.Level)
+	}
+	switch logging.Format {
+	case "", LogFormatText, LogFormatJSON:
+	default:
+		return fmt.Errorf("logging: invalid format %q (must be text or json)", logging.Format)
+	}
+
+	return nil
+}
+
+// validateTelemetry checks the telemetry exporter and its destination
+func validateTelemetry(telemetry *TelemetryConfig) error {
+	if telemetry == nil {
+		return nil
+	}
+
+	switch telemetry.Exporter {
+	case "", ExporterOTLP, ExporterStdout:
+	case ExporterFile:
+		if telemetry.File == "" {
+			return fmt.Errorf("telemetry: 'file' is required for the file exporter")
+		}
+	default:
+		return fmt.Errorf("telemetry: invalid exporter %q (must be otlp, stdout, or file)", telemetry.Exporter)
+	}
+
+	return nil
+}
+
+// validatePolicy checks a server's call policy
+func validatePolicy(name string, policy *CallPolicyConfig) error {
+	if policy == nil {
+		return nil
+	}
+
+	if policy.MaxCallsPerSecond < 0 || policy.MaxConcurrency < 0 {
+		return fmt.Errorf("server %q: policy limits must not be negative", name)
+	}
+	if retry := policy.Retry; retry != nil {
+		if retry.MaxAttempts < 0 || retry.InitialBackoff < 0 || retry.MaxBackoff < 0 {
+			return fmt.Errorf("server %q: policy.retry values must not be negative", name)
+		}
+		for _, pattern := range retry.ErrorPatterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("server %q: invalid policy.retry error pattern %q: %w", name, pattern, err)
+			}
+		}
+	}
+	if breaker := policy.CircuitBreaker; breaker != nil && (breaker.FailureThreshold < 0 || breaker.Cooldown < 0) {
+		return fmt.Errorf("server %q: policy.circuitBreaker values must not be negative", name)
+	}
+
+	return nil
+}
+
 // GetServerPort returns the configured server port with fallback to default
 func (c *Config) GetServerPort() int {
 	if c.Server != nil && c.Server.Port > 0 {
@@ -321,7 +824,7 @@ func (c *Config) GetServerPort() int {
 	return 3000 // Default port
 }
 
-// GetServerTimeout returns the configured timeout with fallback to default
+// GetServerTimeout returns the configured HTTP timeout with fallback to default
 func (c *Config) GetServerTimeout() int {
 	if c.Server != nil && c.Server.Timeout > 0 {
 		return c.Server.Timeout
@@ -329,6 +832,17 @@ func (c *Config) GetServerTimeout() int {
 	return 30 // Default 30 seconds
 }
 
+// writeTimeoutHeadroom is the time in seconds an execute_code response may take
+// beyond the execution deadline, for bundling the code and writing the result
+const writeTimeoutHeadroom = 30
+
+// GetServerWriteTimeout returns the HTTP write timeout in seconds. It is the server
+// timeout, raised if needed so that responses of executions that run until their
+// deadline are not cut off.
+func (c *Config) GetServerWriteTimeout() int {
+	return max(c.GetServerTimeout(), c.GetExecutionTimeout()+writeTimeoutHeadroom)
+}
+
 // GetWasmPath returns the configured WASM path, or empty string to use embedded
 func (c *Config) GetWasmPath() string {
 	if c.Server != nil {
@@ -336,3 +850,295 @@ func (c *Config) GetWasmPath() string {
 	}
 	return ""
 }
+
+// GetMetrics returns the Prometheus metrics settings
+func (c *Config) GetMetrics() MetricsConfig {
+	if c.Server != nil && c.Server.Metrics != nil {
+		return *c.Server.Metrics
+	}
+	return MetricsConfig{}
+}
+
+// GetCodegenValidators returns whether runtime argument validators should be generated
+func (c *Config) GetCodegenValidators() bool {
+	if c.Codegen != nil {
+		return c.Codegen.Validators
+	}
+	return false
+}
+
+// GetTypeCheck returns whether code should be type-checked before bundling
+func (c *Config) GetTypeCheck() bool {
+	if c.Execution != nil {
+		return c.Execution.TypeCheck
+	}
+	return false
+}
+
+// GetExecutionTimeout returns the execute_code deadline in seconds with fallback to default
+func (c *Config) GetExecutionTimeout() int {
+	if c.Execution != nil && c.Execution.Timeout > 0 {
+		return c.Execution.Timeout
+	}
+	return 30 // Default 30 seconds
+}
+
+// GetMaxConcurrentCalls returns the per-execution tool call concurrency with fallback to default
+func (c *Config) GetMaxConcurrentCalls() int {
+	if c.Execution != nil && c.Execution.MaxConcurrentCalls > 0 {
+		return c.Execution.MaxConcurrentCalls
+	}
+	return 8 // Default 8 concurrent calls
+}
+
+// GetForwardConsole returns whether console output of sandbox code is forwarded to the client
+func (c *Config) GetForwardConsole() bool {
+	if c.Execution != nil {
+		return c.Execution.ForwardConsole
+	}
+	return false
+}
+
+// GetTraceVerbosity returns the default tool call trace verbosity
+func (c *Config) GetTraceVerbosity() string {
+	if c.Execution != nil && c.Execution.Trace != nil && c.Execution.Trace.Verbosity != "" {
+		return c.Execution.Trace.Verbosity
+	}
+	return TraceSummary
+}
+
+// GetTraceRedact returns the argument names redacted in traces, including the built-in ones
+func (c *Config) GetTraceRedact() []string {
+	redact := append([]string(nil), defaultTraceRedact...)
+	if c.Execution != nil && c.Execution.Trace != nil {
+		redact = append(redact, c.Execution.Trace.Redact...)
+	}
+	return redact
+}
+
+// GetSemanticSearch returns whether semantic tool search is enabled
+func (c *Config) GetSemanticSearch() bool {
+	if c.Search != nil {
+		return c.Search.Semantic
+	}
+	return false
+}
+
+// GetSemanticWeight returns the hybrid search semantic weight with fallback to default
+func (c *Config) GetSemanticWeight() float64 {
+	if c.Search != nil && c.Search.SemanticWeight != nil {
+		return min(max(*c.Search.SemanticWeight, 0), 1)
+	}
+	return 0.5 // Default equal weighting
+}
+
+// GetSearchCacheDir returns the tool vector cache directory, or empty string to disable caching
+func (c *Config) GetSearchCacheDir() string {
+	if c.Search != nil && c.Search.CacheDir != "" {
+		return c.Search.CacheDir
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cacheDir, "runbyte", "embeddings")
+}
+
+// GetEmbedderTimeout returns the embedding endpoint timeout with fallback to default
+func (c *Config) GetEmbedderTimeout() int {
+	if c.Search != nil && c.Search.Embedder != nil && c.Search.Embedder.Timeout > 0 {
+		return c.Search.Embedder.Timeout
+	}
+	return 30 // Default 30 seconds
+}
+
+// GetMounts returns the sandbox filesystem mounts with default limits and lifecycles applied.
+// Without configured mounts, workspace, cache and temp directories are provided.
+func (c *Config) GetMounts() []MountConfig {
+	var mounts []MountConfig
+	if c.Filesystem != nil && len(c.Filesystem.Mounts) > 0 {
+		mounts = make([]MountConfig, len(c.Filesystem.Mounts))
+		copy(mounts, c.Filesystem.Mounts)
+	} else {
+		mounts = []MountConfig{
+			{Name: "workspace", MaxVersions: defaultMaxVersions},
+			{Name: "cache"},
+			{Name: "temp", Lifecycle: LifecycleExecution},
+		}
+	}
+
+	for i := range mounts {
+		mount := &mounts[i]
+		if mount.MaxFileSize <= 0 {
+			mount.MaxFileSize = defaultMaxFileSize
+		}
+		if mount.MaxFiles <= 0 {
+			mount.MaxFiles = defaultMaxFiles
+		}
+		if mount.MaxTotalSize <= 0 {
+			mount.MaxTotalSize = defaultMaxTotalSize
+		}
+		if mount.Lifecycle == "" {
+			// Never delete host directories the user pointed us at unless asked to
+			if mount.Root != "" {
+				mount.Lifecycle = LifecyclePersistent
+			} else {
+				mount.Lifecycle = LifecycleSession
+			}
+		}
+	}
+
+	return mounts
+}
+
+// GetWorkspaceScope returns the workspace scope with fallback to default
+func (c *Config) GetWorkspaceScope() string {
+	if c.Workspace != nil && c.Workspace.Scope != "" {
+		return c.Workspace.Scope
+	}
+	return ScopeSession
+}
+
+// GetWorkspaceBaseDir returns the directory holding workspaces with fallback to default
+func (c *Config) GetWorkspaceBaseDir() string {
+	if c.Workspace != nil && c.Workspace.BaseDir != "" {
+		return c.Workspace.BaseDir
+	}
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(cacheDir, "runbyte", "workspaces")
+	}
+	return filepath.Join(os.TempDir(), "runbyte", "workspaces")
+}
+
+// GetPrincipalHeader returns the HTTP header identifying the principal, or empty string if not set
+// or not trusted
+func (c *Config) GetPrincipalHeader() string {
+	if c.Workspace != nil && c.Workspace.TrustProxy {
+		return c.Workspace.PrincipalHeader
+	}
+	return ""
+}
+
+// GetKV returns the key-value store quotas with defaults applied
+func (c *Config) GetKV() KVConfig {
+	var kv KVConfig
+	if c.KV != nil {
+		kv = *c.KV
+	}
+	if kv.MaxKeys <= 0 {
+		kv.MaxKeys = defaultKVMaxKeys
+	}
+	if kv.MaxValueSize <= 0 {
+		kv.MaxValueSize = defaultKVMaxValueSize
+	}
+	if kv.MaxTotalSize <= 0 {
+		kv.MaxTotalSize = defaultKVMaxTotalSize
+	}
+	return kv
+}
+
+// GetPolicy returns the server's call policy with defaults applied to the configured parts
+func (s McpServerConfig) GetPolicy() CallPolicyConfig {
+	var policy CallPolicyConfig
+	if s.Policy != nil {
+		policy = *s.Policy
+	}
+	if policy.Retry != nil {
+		retry := *policy.Retry
+		if retry.MaxAttempts <= 0 {
+			retry.MaxAttempts = defaultRetryMaxAttempts
+		}
+		if retry.InitialBackoff <= 0 {
+			retry.InitialBackoff = defaultRetryInitialBackoff
+		}
+		if retry.MaxBackoff <= 0 {
+			retry.MaxBackoff = defaultRetryMaxBackoff
+		}
+		policy.Retry = &retry
+	}
+	if policy.CircuitBreaker != nil {
+		breaker := *policy.CircuitBreaker
+		if breaker.FailureThreshold <= 0 {
+			breaker.FailureThreshold = defaultBreakerThreshold
+		}
+		if breaker.Cooldown <= 0 {
+			breaker.Cooldown = defaultBreakerCooldown
+		}
+		policy.CircuitBreaker = &breaker
+	}
+	return policy
+}
+
+// GetCache returns the cache settings with default limits applied
+func (c *Config) GetCache() CacheConfig {
+	var cache CacheConfig
+	if c.Cache != nil {
+		cache = *c.Cache
+	}
+	if cache.TTL <= 0 {
+		cache.TTL = defaultCacheTTL
+	}
+	if cache.MaxEntries <= 0 {
+		cache.MaxEntries = defaultCacheMaxEntries
+	}
+	if cache.MaxEntrySize <= 0 {
+		cache.MaxEntrySize = defaultCacheMaxEntrySize
+	}
+	if cache.MaxTotalSize <= 0 {
+		cache.MaxTotalSize = defaultCacheMaxTotalSize
+	}
+	if cache.Scope == "" {
+		cache.Scope = CacheScopeSession
+	}
+	return cache
+}
+
+// GetLogging returns the logging settings with defaults applied
+func (c *Config) GetLogging() LoggingConfig {
+	var logging LoggingConfig
+	if c.Logging != nil {
+		logging = *c.Logging
+	}
+	logging.Level = strings.ToLower(logging.Level)
+	if logging.Level == "" {
+		logging.Level = "info"
+	}
+	if logging.Format == "" {
+		logging.Format = LogFormatText
+	}
+	return logging
+}
+
+// GetTelemetry returns the telemetry settings with defaults applied.
+// An empty exporter means tracing is disabled.
+func (c *Config) GetTelemetry() TelemetryConfig {
+	var telemetry TelemetryConfig
+	if c.Telemetry != nil {
+		telemetry = *c.Telemetry
+	}
+	if telemetry.Endpoint == "" {
+		telemetry.Endpoint = defaultTelemetryEndpoint
+	}
+	if telemetry.ServiceName == "" {
+		telemetry.ServiceName = defaultTelemetryServiceName
+	}
+	return telemetry
+}
+
+// GetFetch returns the fetch settings with default limits applied
+func (c *Config) GetFetch() FetchConfig {
+	var fetch FetchConfig
+	if c.Fetch != nil {
+		fetch = *c.Fetch
+	}
+	if fetch.MaxRequestSize <= 0 {
+		fetch.MaxRequestSize = defaultFetchMaxRequestSize
+	}
+	if fetch.MaxResponseSize <= 0 {
+		fetch.MaxResponseSize = defaultFetchMaxResponseSize
+	}
+	if fetch.Timeout <= 0 {
+		fetch.Timeout = defaultFetchTimeout
+	}
+	return fetch
+}
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/yousuf/runbyte/internal/config"
+)
+
+// Attribute keys identifying where a log record comes from
+const (
+	SessionKey = "session_id" // MCP session
+	RequestKey = "request_id" // MCP request handled by the server
+)
+
+// Setup installs the default slog logger as configured. Records go to the log file,
+// or to stderr without one, and never to stdout, which carries the MCP protocol in
+// stdio mode. Messages of the standard log package are routed through it as well.
+// The returned closer closes the log file, if any.
+func Setup(cfg config.LoggingConfig) (io.Closer, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+
+	var out io.Writer = os.Stderr
+	var closer io.Closer = io.NopCloser(nil)
+	if cfg.File != "" {
+		if err := os.MkdirAll(filepath.Dir(cfg.File), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		file, err := os.OpenFile(cfg.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		out, closer = file, file
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if cfg.Format == config.LogFormatJSON {
+		handler = slog.NewJSONHandler(out, opts)
+	} else {
+		handler = slog.NewTextHandler(out, opts)
+	}
+
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return closer, nil
+}
+
+type attrsKey struct{}
+
+// With returns a context whose log records carry attrs, in addition to the attributes of ctx.
+// Records pick them up when logged with a context, e.g. slog.InfoContext.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	combined := make([]slog.Attr, 0, len(existing)+len(attrs))
+	combined = append(combined, existing...)
+	combined = append(combined, attrs...)
+	return context.WithValue(ctx, attrsKey{}, combined)
+}
+
+// contextHandler adds the attributes carried by the context of each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,251 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DurationBuckets are histogram buckets in seconds, from 5ms up to the longest executions
+var DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Metric types of the Prometheus text format
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// registry holds all metrics in registration order
+var registry struct {
+	mu       sync.Mutex
+	families []*family
+	hooks    []func()
+}
+
+// family is a metric with its labelled series
+type family struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64 // Upper bounds of histogram buckets
+
+	mu     sync.Mutex
+	series map[string]*series // Keyed by joined label values
+}
+
+// series is one combination of label values
+type series struct {
+	values []string
+	value  float64  // Counter or gauge value
+	counts []uint64 // Observations per histogram bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func register(name, help, typ string, buckets []float64, labels []string) *family {
+	f := &family{
+		name:    name,
+		help:    help,
+		typ:     typ,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+
+	registry.mu.Lock()
+	registry.families = append(registry.families, f)
+	registry.mu.Unlock()
+	return f
+}
+
+// get returns the series of the label values, creating it on first use; the caller must hold f.mu
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s: got %d label values, want %d", f.name, len(values), len(f.labels)))
+	}
+
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		if f.typ == typeHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Counter is a monotonically increasing value per combination of label values
+type Counter struct{ f *family }
+
+// NewCounter registers a counter. Names of counters end in _total by convention.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{register(name, help, typeCounter, nil, labels)}
+}
+
+// Inc adds one to the series of the label values
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v, which must not be negative, to the series of the label values
+func (c *Counter) Add(v float64, values ...string) {
+	c.f.mu.Lock()
+	c.f.get(values).value += v
+	c.f.mu.Unlock()
+}
+
+// Gauge is a value that goes up and down per combination of label values
+type Gauge struct{ f *family }
+
+// NewGauge registers a gauge
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{register(name, help, typeGauge, nil, labels)}
+}
+
+// Set sets the series of the label values to v
+func (g *Gauge) Set(v float64, values ...string) {
+	g.f.mu.Lock()
+	g.f.get(values).value = v
+	g.f.mu.Unlock()
+}
+
+// Add adds v, which may be negative, to the series of the label values
+func (g *Gauge) Add(v float64, values ...string) {
+	g.f.mu.Lock()
+	g.f.get(values).value += v
+	g.f.mu.Unlock()
+}
+
+// Reset removes all series, e.g. before setting the current set of label values
+func (g *Gauge) Reset() {
+	g.f.mu.Lock()
+	g.f.series = make(map[string]*series)
+	g.f.mu.Unlock()
+}
+
+// Histogram counts observations in buckets per combination of label values
+type Histogram struct{ f *family }
+
+// NewHistogram registers a histogram with the given ascending bucket upper bounds
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{register(name, help, typeHistogram, buckets, labels)}
+}
+
+// Observe records a value in the series of the label values
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+
+	s := h.f.get(values)
+	s.sum += v
+	s.count++
+	if i := sort.SearchFloat64s(h.f.buckets, v); i < len(s.counts) {
+		s.counts[i]++
+	}
+}
+
+// OnScrape registers a function that updates gauges before each scrape,
+// for values that are cheaper to read on demand than to track
+func OnScrape(hook func()) {
+	registry.mu.Lock()
+	registry.hooks = append(registry.hooks, hook)
+	registry.mu.Unlock()
+}
+
+// Handler serves all metrics in the Prometheus text exposition format
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registry.mu.Lock()
+		hooks := append([]func(){}, registry.hooks...)
+		families := append([]*family{}, registry.families...)
+		registry.mu.Unlock()
+
+		for _, hook := range hooks {
+			hook()
+		}
+
+		var buf bytes.Buffer
+		for _, f := range families {
+			f.write(&buf)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+}
+
+// write appends the family in the text exposition format, with series sorted by label values
+func (f *family) write(buf *bytes.Buffer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(buf, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(buf, "# TYPE %s %s\n", f.name, f.typ)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.typ != typeHistogram {
+			fmt.Fprintf(buf, "%s%s %s\n", f.name, f.labelPairs(s.values, ""), formatValue(s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(buf, "%s_bucket%s %d\n", f.name, f.labelPairs(s.values, formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(buf, "%s_bucket%s %d\n", f.name, f.labelPairs(s.values, "+Inf"), s.count)
+		fmt.Fprintf(buf, "%s_sum%s %s\n", f.name, f.labelPairs(s.values, ""), formatValue(s.sum))
+		fmt.Fprintf(buf, "%s_count%s %d\n", f.name, f.labelPairs(s.values, ""), s.count)
+	}
+}
+
+// labelPairs formats label values as {name="value",...}, adding le for histogram buckets
+func (f *family) labelPairs(values []string, le string) string {
+	if len(values) == 0 && le == "" {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(values)+1)
+	for i, value := range values {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", f.labels[i], escapeLabel(value)))
+	}
+	if le != "" {
+		pairs = append(pairs, fmt.Sprintf("le=\"%s\"", le))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
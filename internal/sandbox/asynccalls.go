@@ -0,0 +1,182 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/yousuf/runbyte/internal/client"
+)
+
+// DefaultMaxConcurrentCalls is the number of MCP tool calls an execution runs at once
+const DefaultMaxConcurrentCalls = 8
+
+// callNotStarted is the error of calls still waiting for a slot when the execution ends
+const callNotStarted = "execution finished before the tool call started"
+
+// Options configures a sandbox
+type Options struct {
+	MaxConcurrentCalls int          // MCP tool calls running at once (defaults to DefaultMaxConcurrentCalls)
+	Trace              TraceOptions // Tool call trace returned with the result
+	Log                LogFunc      // Receives log messages of the execution, e.g. to forward them to the MCP client (optional)
+	ForwardConsole     bool         // Pass console.* output of sandbox code to Log
+}
+
+// PollRequest represents a wait for submitted MCP tool calls from WASM
+type PollRequest struct {
+	WaitMs int64 `json:"waitMs"` // Time to wait for a call to complete; negative waits until one does
+}
+
+// PollResponse returns the MCP tool calls completed since the last poll
+type PollResponse struct {
+	Success   bool              `json:"success"`
+	Completed []McpToolResponse `json:"completed,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// toolCalls runs the MCP tool calls of an execution concurrently, up to a limit
+type toolCalls struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	clientHub *client.McpClientHub
+	recorder  client.CallRecorder // Effect of server call policies on this execution
+	trace     *callTrace          // Calls made by this execution
+	slots     chan struct{}       // Semaphore limiting calls in flight
+	ready     chan struct{}       // Signalled when a call completes
+	wg        sync.WaitGroup
+
+	mu        sync.Mutex
+	next      int64
+	pending   int
+	completed []McpToolResponse
+}
+
+// newToolCalls creates the call runner of an execution
+func newToolCalls(ctx context.Context, clientHub *client.McpClientHub, opts Options) *toolCalls {
+	limit := opts.MaxConcurrentCalls
+	if limit <= 0 {
+		limit = DefaultMaxConcurrentCalls
+	}
+	t := &toolCalls{
+		clientHub: clientHub,
+		trace:     newCallTrace(opts.Trace),
+		slots:     make(chan struct{}, limit),
+		ready:     make(chan struct{}, 1),
+	}
+	t.ctx, t.cancel = context.WithCancel(client.WithCallRecorder(ctx, &t.recorder))
+	return t
+}
+
+// Submit starts a tool call in the background and returns its handle
+func (t *toolCalls) Submit(call McpToolCall) int64 {
+	handle := t.begin()
+	entry := t.trace.begin(call)
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		response := t.run(call, entry)
+		response.Handle = handle
+		t.complete(response)
+	}()
+
+	return handle
+}
+
+// begin allocates the handle of a new pending call or batch
+func (t *toolCalls) begin() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.next++
+	t.pending++
+	return t.next
+}
+
+// run performs a tool call once a slot is free, and records it in the trace entry
+func (t *toolCalls) run(call McpToolCall, entry *TraceEntry) (response McpToolResponse) {
+	defer func() {
+		t.trace.finish(t.ctx, entry, response)
+	}()
+
+	select {
+	case t.slots <- struct{}{}:
+		callSlotsInUse.Add(1)
+		defer func() {
+			<-t.slots
+			callSlotsInUse.Add(-1)
+		}()
+		return executeToolCall(t.ctx, t.clientHub, call)
+	case <-t.ctx.Done():
+		return McpToolResponse{Error: callNotStarted}
+	}
+}
+
+// complete records a finished call or batch and wakes up a waiting poll
+func (t *toolCalls) complete(response McpToolResponse) {
+	t.mu.Lock()
+	t.pending--
+	t.completed = append(t.completed, response)
+	t.mu.Unlock()
+
+	select {
+	case t.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Poll returns the calls completed since the last poll, waiting up to wait for one
+// to complete. A negative wait blocks until a call completes or ctx is done.
+func (t *toolCalls) Poll(wait time.Duration) []McpToolResponse {
+	var timeout <-chan time.Time
+	if wait >= 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		t.mu.Lock()
+		completed, pending := t.completed, t.pending
+		t.completed = nil
+		t.mu.Unlock()
+
+		if len(completed) > 0 || pending == 0 {
+			return completed
+		}
+
+		select {
+		case <-t.ready:
+		case <-timeout:
+			return nil
+		case <-t.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Close cancels calls still running and waits for them to finish
+func (t *toolCalls) Close() {
+	t.cancel()
+	t.wg.Wait()
+}
+
+// handlePoll collects completed MCP tool calls for the sandbox
+func (s *Sandbox) handlePoll(requestJSON []byte) (response []byte) {
+	var req PollRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(PollResponse{Success: false, Error: "invalid request"})
+	}
+
+	var completed []McpToolResponse
+	s.clock.timeHost(func() {
+		completed = s.calls.Poll(time.Duration(req.WaitMs) * time.Millisecond)
+	})
+	if len(completed) == 0 && s.ctx.Err() != nil {
+		return mustMarshal(PollResponse{Success: false, Error: "execution cancelled while waiting for tool calls"})
+	}
+
+	return mustMarshal(PollResponse{Success: true, Completed: completed})
+}
@@ -0,0 +1,105 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// MaxBatchCalls is the maximum number of calls in a single batch
+const MaxBatchCalls = 1000
+
+// McpToolBatch represents a batch of MCP tool calls from WASM
+type McpToolBatch struct {
+	Calls       []McpToolCall `json:"calls"`
+	Concurrency int           `json:"concurrency,omitempty"` // Calls of the batch running at once, within the execution limit
+	Dedupe      bool          `json:"dedupe,omitempty"`      // Run identical calls once and share their result
+}
+
+// SubmitBatch starts a batch of tool calls in the background and returns its handle.
+// The batch completes once all its calls have, with their results in call order.
+func (t *toolCalls) SubmitBatch(batch McpToolBatch) int64 {
+	handle := t.begin()
+
+	// With deduplication, each call runs at the index of its first occurrence
+	first := make([]int, len(batch.Calls))
+	entries := make([]*TraceEntry, len(batch.Calls))
+	seen := make(map[string]int)
+	for i, call := range batch.Calls {
+		first[i] = i
+		if batch.Dedupe {
+			key := callKey(call)
+			if j, ok := seen[key]; ok {
+				first[i] = j
+				continue
+			}
+			seen[key] = i
+		}
+		entries[i] = t.trace.begin(call)
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		t.complete(McpToolResponse{Handle: handle, Results: t.runBatch(batch, first, entries)})
+	}()
+
+	return handle
+}
+
+// runBatch performs the calls of a batch, at most batch.Concurrency at a time
+func (t *toolCalls) runBatch(batch McpToolBatch, first []int, entries []*TraceEntry) []McpToolResponse {
+	limit := batch.Concurrency
+	if limit <= 0 {
+		limit = len(batch.Calls)
+	}
+	batchSlots := make(chan struct{}, max(limit, 1))
+
+	results := make([]McpToolResponse, len(batch.Calls))
+	var wg sync.WaitGroup
+	for i, call := range batch.Calls {
+		if first[i] != i {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case batchSlots <- struct{}{}:
+				results[i] = t.run(call, entries[i])
+				<-batchSlots
+			case <-t.ctx.Done():
+				results[i] = McpToolResponse{Error: callNotStarted}
+				t.trace.finish(t.ctx, entries[i], results[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, j := range first {
+		results[i] = results[j]
+	}
+	return results
+}
+
+// callKey identifies identical calls. Arguments are marshaled with sorted map keys.
+func callKey(call McpToolCall) string {
+	args, _ := json.Marshal(call.Args)
+	return fmt.Sprintf("%s\x00%s\x00%s", call.ServerName, call.ToolName, args)
+}
+
+// handleCallBatch submits a batch of MCP tool calls from WASM
+func (s *Sandbox) handleCallBatch(requestJSON []byte) []byte {
+	var batch McpToolBatch
+	if err := json.Unmarshal(requestJSON, &batch); err != nil {
+		return mustMarshal(McpToolResponse{Error: "Invalid tool call batch format"})
+	}
+	if len(batch.Calls) > MaxBatchCalls {
+		return mustMarshal(McpToolResponse{Error: fmt.Sprintf("batch has %d calls (max %d)", len(batch.Calls), MaxBatchCalls)})
+	}
+
+	return mustMarshal(McpToolResponse{Handle: s.calls.SubmitBatch(batch)})
+}
@@ -0,0 +1,291 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxFetchRedirects is the number of redirects followed before a request fails
+const maxFetchRedirects = 10
+
+// defaultFetchMethods are allowed when a rule does not list methods
+var defaultFetchMethods = []string{http.MethodGet, http.MethodHead}
+
+// FetchPolicy controls which HTTP requests sandbox code may make
+type FetchPolicy struct {
+	Rules           []FetchRule
+	MaxRequestSize  int64         // Maximum request body size in bytes
+	MaxResponseSize int64         // Maximum response body size in bytes
+	Timeout         time.Duration // Per-request timeout, shortened by the execution deadline
+}
+
+// FetchRule allows requests to a host
+type FetchRule struct {
+	Host    string            // Host name, optionally with port; "*.example.com" matches subdomains
+	Methods []string          // Allowed methods (defaults to GET and HEAD)
+	Headers map[string]string // Headers injected into every request; never visible to sandbox code
+}
+
+// FetchRequest represents an HTTP request from WASM
+type FetchRequest struct {
+	URL      string            `json:"url"`
+	Method   string            `json:"method,omitempty"` // Defaults to GET
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	Encoding string            `json:"encoding,omitempty"` // Encoding of Body
+}
+
+// FetchResponse represents an HTTP response returned to WASM
+type FetchResponse struct {
+	Success    bool              `json:"success"`
+	Status     int               `json:"status,omitempty"`
+	StatusText string            `json:"statusText,omitempty"`
+	URL        string            `json:"url,omitempty"` // Final URL after redirects
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	Encoding   string            `json:"encoding,omitempty"` // utf8 for text bodies, otherwise base64
+	Error      string            `json:"error,omitempty"`
+}
+
+// FetchAuditEntry records an outbound request made by sandbox code.
+// Injected headers and query strings are never recorded.
+type FetchAuditEntry struct {
+	Time          time.Time `json:"time"`
+	SessionID     string    `json:"sessionId,omitempty"`
+	Method        string    `json:"method"`
+	URL           string    `json:"url"`
+	Status        int       `json:"status,omitempty"`
+	RequestBytes  int64     `json:"requestBytes"`
+	ResponseBytes int64     `json:"responseBytes"`
+	DurationMs    int64     `json:"durationMs"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// Fetcher performs HTTP requests for sandbox code within a FetchPolicy
+type Fetcher struct {
+	policy FetchPolicy
+	client *http.Client
+	audit  func(entry FetchAuditEntry)
+}
+
+// NewFetcher creates a fetcher enforcing policy. audit is called once per request, allowed or not.
+func NewFetcher(policy FetchPolicy, audit func(entry FetchAuditEntry)) *Fetcher {
+	f := &Fetcher{policy: policy, audit: audit}
+	f.client = &http.Client{CheckRedirect: f.checkRedirect}
+	return f
+}
+
+// Fetch performs an HTTP request if the policy allows it
+func (f *Fetcher) Fetch(ctx context.Context, req FetchRequest) (resp *FetchResponse, err error) {
+	start := time.Now()
+	entry := FetchAuditEntry{Time: start, Method: strings.ToUpper(req.Method), URL: auditURL(req.URL)}
+	if entry.Method == "" {
+		entry.Method = http.MethodGet
+	}
+	defer func() {
+		entry.DurationMs = time.Since(start).Milliseconds()
+		if err != nil {
+			entry.Error = err.Error()
+		} else {
+			entry.Status = resp.Status
+			entry.URL = auditURL(resp.URL)
+		}
+		if f.audit != nil {
+			f.audit(entry)
+		}
+	}()
+
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q: only absolute http and https URLs are supported", req.URL)
+	}
+	if target.User != nil {
+		return nil, errors.New("URLs with credentials are not supported; configure headers in fetch.allow instead")
+	}
+
+	rule, err := f.match(target, entry.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := decodeContent(req.Body, req.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	entry.RequestBytes = int64(len(body))
+	if entry.RequestBytes > f.policy.MaxRequestSize {
+		return nil, fmt.Errorf("request body exceeds limit: %d bytes (max %d)", len(body), f.policy.MaxRequestSize)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.policy.Timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, entry.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	for key, val := range req.Headers {
+		httpReq.Header.Set(key, val)
+	}
+	for key, val := range rule.Headers {
+		httpReq.Header.Set(key, val)
+	}
+
+	httpResp, err := f.client.Do(httpReq)
+	if err != nil {
+		return nil, fetchError(ctx, err)
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, f.policy.MaxResponseSize+1))
+	entry.ResponseBytes = int64(len(data))
+	if err != nil {
+		return nil, fetchError(ctx, err)
+	}
+	if entry.ResponseBytes > f.policy.MaxResponseSize {
+		return nil, fmt.Errorf("response body exceeds limit of %d bytes", f.policy.MaxResponseSize)
+	}
+
+	resp = &FetchResponse{
+		Success:    true,
+		Status:     httpResp.StatusCode,
+		StatusText: strings.TrimSpace(strings.TrimPrefix(httpResp.Status, fmt.Sprint(httpResp.StatusCode))),
+		URL:        httpResp.Request.URL.String(),
+		Headers:    make(map[string]string, len(httpResp.Header)),
+	}
+	for key, vals := range httpResp.Header {
+		resp.Headers[strings.ToLower(key)] = strings.Join(vals, ", ")
+	}
+	if utf8.Valid(data) {
+		resp.Body, resp.Encoding = string(data), EncodingUTF8
+	} else {
+		resp.Body, resp.Encoding = base64.StdEncoding.EncodeToString(data), EncodingBase64
+	}
+
+	return resp, nil
+}
+
+// HandleFetch processes a fetch request from WASM
+func (f *Fetcher) HandleFetch(ctx context.Context, requestJSON []byte) []byte {
+	if f == nil {
+		return mustMarshal(FetchResponse{Success: false, Error: "fetch is not enabled - add hosts to fetch.allow in runbyte.json"})
+	}
+
+	var req FetchRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(FetchResponse{Success: false, Error: "invalid request"})
+	}
+
+	resp, err := f.Fetch(ctx, req)
+	if err != nil {
+		return mustMarshal(FetchResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(resp)
+}
+
+// match finds the rule allowing a request
+func (f *Fetcher) match(target *url.URL, method string) (FetchRule, error) {
+	hostAllowed := false
+	for _, rule := range f.policy.Rules {
+		if !rule.matchesHost(target) {
+			continue
+		}
+		hostAllowed = true
+		if rule.allowsMethod(method) {
+			return rule, nil
+		}
+	}
+
+	if hostAllowed {
+		return FetchRule{}, fmt.Errorf("method %s is not allowed for host %s", method, target.Host)
+	}
+	return FetchRule{}, fmt.Errorf("host %s is not in the fetch allowlist", target.Host)
+}
+
+// checkRedirect applies the allowlist to redirects, and swaps injected headers for
+// those of the rule matching the new location so credentials never leak to other hosts
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxFetchRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+	}
+
+	rule, err := f.match(req.URL, req.Method)
+	if err != nil {
+		return fmt.Errorf("redirect to %s blocked: %w", auditURL(req.URL.String()), err)
+	}
+
+	for _, other := range f.policy.Rules {
+		for key := range other.Headers {
+			req.Header.Del(key)
+		}
+	}
+	for key, val := range rule.Headers {
+		req.Header.Set(key, val)
+	}
+	return nil
+}
+
+// matchesHost reports whether the rule covers a URL's host and port
+func (r FetchRule) matchesHost(target *url.URL) bool {
+	ruleHost, rulePort := strings.ToLower(r.Host), ""
+	if host, port, err := net.SplitHostPort(ruleHost); err == nil {
+		ruleHost, rulePort = host, port
+	}
+	if rulePort != "" && rulePort != target.Port() {
+		return false
+	}
+
+	host := strings.ToLower(target.Hostname())
+	if suffix, ok := strings.CutPrefix(ruleHost, "*"); ok {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return host == ruleHost
+}
+
+// allowsMethod reports whether the rule allows an HTTP method
+func (r FetchRule) allowsMethod(method string) bool {
+	methods := r.Methods
+	if len(methods) == 0 {
+		methods = defaultFetchMethods
+	}
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchError explains request failures, without repeating URLs that may carry secrets in their query
+func fetchError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return errors.New("request timed out (fetch timeout or execution deadline reached)")
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	return fmt.Errorf("request failed: %w", err)
+}
+
+// auditURL removes credentials, query and fragment from a URL before it is logged
+func auditURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "(invalid URL)"
+	}
+	u.User, u.RawQuery, u.Fragment = nil, "", ""
+	return u.String()
+}
@@ -1,13 +1,27 @@
 package sandbox
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
+)
+
+// Lifecycle defines how long the contents of a directory are kept
+type Lifecycle string
+
+const (
+	LifecyclePersistent Lifecycle = "persistent" // Kept when the session ends
+	LifecycleSession    Lifecycle = "session"    // Removed when the session ends
+	LifecycleExecution  Lifecycle = "execution"  // Each code execution gets an empty subdirectory, removed when it finishes
 )
 
 // DirectoryConfig defines permissions and limits for a directory
@@ -18,11 +32,16 @@ type DirectoryConfig struct {
 	MaxFileSize  int64
 	MaxFiles     int
 	MaxTotalSize int64
+	Lifecycle    Lifecycle // Defaults to LifecycleSession
+	MaxVersions  int       // Previous versions kept per file (0 disables versioning)
 }
 
 // SandboxFileSystem manages all filesystem operations for a sandbox
 type SandboxFileSystem struct {
 	directories map[string]*Directory // Key: directory name (workspace, cache, etc.)
+	names       []string              // Directory names in mount order
+	onChange    func(dirName, relPath string)
+	serversDir  string // Generated server libraries, searchable as '/servers'
 	mu          sync.RWMutex
 }
 
@@ -31,55 +50,171 @@ type Directory struct {
 	config DirectoryConfig
 	stats  Stats
 	mu     sync.RWMutex
+
+	locks   map[string]*pathLock // Advisory path locks in use, keyed by cleaned relative path
+	locksMu sync.Mutex
+
+	mounts int // Filesystems the directory is mounted in, guarded by mu
 }
 
+// reservedPrefix marks files used internally by runbyte (e.g., the key-value store),
+// which sandbox code cannot access and which are hidden from listings and stats
+const reservedPrefix = ".runbyte-"
+
+// tempFilePrefix marks in-progress atomic writes
+const tempFilePrefix = reservedPrefix + "tmp-"
+
+// executionDirPrefix marks the subdirectory a per-execution directory gives each execution
+const executionDirPrefix = reservedPrefix + "exec-"
+
+// staleTempFileAge is the age after which leftover temp files from interrupted writes are removed
+const staleTempFileAge = time.Hour
+
 // Stats tracks directory usage
 type Stats struct {
-	TotalBytes  int64
-	FileCount   int
-	MaxFileSize int64
+	TotalBytes   int64
+	FileCount    int
+	MaxFileSize  int64
+	VersionBytes int64 // Bytes retained by previous file versions (also count towards MaxTotalSize)
 }
 
+// Content encodings used to transfer file data across the WASM boundary
+const (
+	EncodingUTF8   = "utf8"   // Content is passed as a string (default)
+	EncodingBase64 = "base64" // Content is base64-encoded binary data
+)
+
 // FileRequest represents a filesystem operation request
 type FileRequest struct {
-	Path    string `json:"path"`
-	Content string `json:"content,omitempty"`
+	Path        string `json:"path"`
+	Content     string `json:"content,omitempty"`
+	Encoding    string `json:"encoding,omitempty"`    // Encoding of Content and response Data
+	Offset      int64  `json:"offset,omitempty"`      // Byte offset for ranged reads
+	Length      int64  `json:"length,omitempty"`      // Maximum bytes for ranged reads (0 reads to end of file)
+	Destination string `json:"destination,omitempty"` // Target path for rename and copy
+	Version     string `json:"version,omitempty"`     // Version ID for restore
 }
 
 // FileResponse represents a filesystem operation response
 type FileResponse struct {
-	Success bool     `json:"success"`
-	Data    string   `json:"data,omitempty"`
-	Files   []string `json:"files,omitempty"`
-	Error   string   `json:"error,omitempty"`
+	Success  bool          `json:"success"`
+	Data     string        `json:"data,omitempty"`
+	Files    []string      `json:"files,omitempty"`
+	Stat     *FileInfo     `json:"stat,omitempty"`
+	Versions []VersionInfo `json:"versions,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// FileInfo describes a file or directory
+type FileInfo struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtimeMs"` // Unix milliseconds
+	IsDir   bool   `json:"isDirectory"`
 }
 
 // NewSandboxFileSystem creates a new filesystem with multiple directories
 func NewSandboxFileSystem(directories []DirectoryConfig) (*SandboxFileSystem, error) {
+	dirs := make([]*Directory, 0, len(directories))
+	for _, cfg := range directories {
+		dir, err := NewDirectory(cfg)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, dir)
+	}
+
+	return NewSandboxFileSystemWithDirectories(dirs)
+}
+
+// NewSandboxFileSystemWithDirectories creates a filesystem from existing directories.
+// A directory may be mounted in several filesystems, which then share its quota and stats.
+func NewSandboxFileSystemWithDirectories(directories []*Directory) (*SandboxFileSystem, error) {
 	sfs := &SandboxFileSystem{
 		directories: make(map[string]*Directory),
 	}
 
-	for _, cfg := range directories {
-		// Create directory on disk
-		if err := os.MkdirAll(cfg.Root, 0755); err != nil {
-			return nil, fmt.Errorf("failed to create directory %s: %w", cfg.Name, err)
+	for _, dir := range directories {
+		name := dir.config.Name
+		if _, exists := sfs.directories[name]; exists {
+			return nil, fmt.Errorf("duplicate directory %s", name)
 		}
 
-		dir := &Directory{
-			config: cfg,
-			stats:  Stats{},
-		}
+		sfs.directories[name] = dir
+		sfs.names = append(sfs.names, name)
+	}
+
+	for _, dir := range directories {
+		dir.mu.Lock()
+		dir.mounts++
+		dir.mu.Unlock()
+	}
+
+	return sfs, nil
+}
+
+// ForExecution returns the filesystem seen by a single code execution. Per-execution
+// directories are replaced by a new subdirectory of their root, so concurrent executions
+// never see or clear each other's files; the other directories are shared.
+// Call RemoveExecutionDirectories once the execution finishes.
+func (sfs *SandboxFileSystem) ForExecution() (*SandboxFileSystem, error) {
+	sfs.mu.RLock()
+	defer sfs.mu.RUnlock()
 
-		// Calculate initial stats
-		if err := dir.recalculateStats(); err != nil {
-			return nil, fmt.Errorf("failed to calculate stats for %s: %w", cfg.Name, err)
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate execution ID: %w", err)
+	}
+
+	execFS := &SandboxFileSystem{
+		directories: make(map[string]*Directory, len(sfs.directories)),
+		names:       sfs.names,
+		onChange:    sfs.onChange,
+		serversDir:  sfs.serversDir,
+	}
+	for name, dir := range sfs.directories {
+		if dir.config.Lifecycle == LifecycleExecution {
+			cfg := dir.config
+			cfg.Root = filepath.Join(cfg.Root, executionDirPrefix+hex.EncodeToString(id))
+			execDir, err := NewDirectory(cfg)
+			if err != nil {
+				execFS.RemoveExecutionDirectories()
+				return nil, err
+			}
+			dir = execDir
 		}
+		execFS.directories[name] = dir
+	}
+
+	return execFS, nil
+}
 
-		sfs.directories[cfg.Name] = dir
+// NewDirectory creates a directory on disk and calculates its initial stats
+func NewDirectory(cfg DirectoryConfig) (*Directory, error) {
+	if cfg.Lifecycle == "" {
+		cfg.Lifecycle = LifecycleSession
 	}
 
-	return sfs, nil
+	// Create directory on disk
+	if err := os.MkdirAll(cfg.Root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", cfg.Name, err)
+	}
+
+	dir := &Directory{
+		config: cfg,
+		stats:  Stats{},
+		locks:  make(map[string]*pathLock),
+	}
+
+	// Remove temp files left behind by interrupted writes
+	dir.removeStaleTempFiles()
+
+	// Calculate initial stats
+	if err := dir.recalculateStats(); err != nil {
+		return nil, fmt.Errorf("failed to calculate stats for %s: %w", cfg.Name, err)
+	}
+
+	return dir, nil
 }
 
 // parsePath extracts directory name and relative path from user path
@@ -109,11 +244,7 @@ func (sfs *SandboxFileSystem) parsePath(userPath string) (dirName string, relPat
 
 	// Check if directory exists
 	if _, ok := sfs.directories[dirName]; !ok {
-		available := make([]string, 0, len(sfs.directories))
-		for name := range sfs.directories {
-			available = append(available, name)
-		}
-		return "", "", fmt.Errorf("unknown directory '%s', available: %v", dirName, available)
+		return "", "", fmt.Errorf("unknown directory '%s', available: %v", dirName, sfs.names)
 	}
 
 	return dirName, relPath, nil
@@ -133,6 +264,16 @@ func (sfs *SandboxFileSystem) ReadFile(userPath string) (string, error) {
 	return dir.ReadFile(relPath)
 }
 
+// ReadBytes reads up to length bytes of a file starting at offset (length 0 reads to end of file)
+func (sfs *SandboxFileSystem) ReadBytes(userPath string, offset, length int64) ([]byte, error) {
+	dir, relPath, err := sfs.resolve(userPath, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return dir.ReadBytes(relPath, offset, length)
+}
+
 // WriteFile writes a file to any writable directory
 func (sfs *SandboxFileSystem) WriteFile(userPath, content string) error {
 	dirName, relPath, err := sfs.parsePath(userPath)
@@ -148,7 +289,158 @@ func (sfs *SandboxFileSystem) WriteFile(userPath, content string) error {
 		return fmt.Errorf("directory '%s' is read-only", dirName)
 	}
 
-	return dir.WriteFile(relPath, content)
+	if err := dir.WriteFile(relPath, content); err != nil {
+		return err
+	}
+	sfs.changed(dir, relPath)
+	return nil
+}
+
+// WriteBytes writes binary data to a file in any writable directory
+func (sfs *SandboxFileSystem) WriteBytes(userPath string, data []byte) error {
+	dir, relPath, err := sfs.resolve(userPath, true)
+	if err != nil {
+		return err
+	}
+
+	if err := dir.WriteBytes(relPath, data); err != nil {
+		return err
+	}
+	sfs.changed(dir, relPath)
+	return nil
+}
+
+// AppendFile appends data to a file in any writable directory, creating it if needed
+func (sfs *SandboxFileSystem) AppendFile(userPath string, data []byte) error {
+	dir, relPath, err := sfs.resolve(userPath, true)
+	if err != nil {
+		return err
+	}
+
+	if err := dir.AppendFile(relPath, data); err != nil {
+		return err
+	}
+	sfs.changed(dir, relPath)
+	return nil
+}
+
+// Stat returns information about a file or directory
+func (sfs *SandboxFileSystem) Stat(userPath string) (FileInfo, error) {
+	dir, relPath, err := sfs.resolve(userPath, false)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	return dir.Stat(relPath)
+}
+
+// Mkdir creates a directory and any missing parents in a writable directory
+func (sfs *SandboxFileSystem) Mkdir(userPath string) error {
+	dir, relPath, err := sfs.resolve(userPath, true)
+	if err != nil {
+		return err
+	}
+
+	return dir.Mkdir(relPath)
+}
+
+// Rename moves a file or directory. Moves between directories are limited to files
+// and are checked against the destination's limits.
+func (sfs *SandboxFileSystem) Rename(srcPath, dstPath string) error {
+	srcDir, srcRel, err := sfs.resolve(srcPath, true)
+	if err != nil {
+		return err
+	}
+	dstDir, dstRel, err := sfs.resolve(dstPath, true)
+	if err != nil {
+		return err
+	}
+
+	// Renaming a directory moves the files under it, whose subscribers are notified as well
+	var movedFiles []string
+	if srcDir == dstDir {
+		if info, statErr := srcDir.Stat(srcRel); statErr == nil && info.IsDir {
+			srcDir.WalkFiles(srcRel, func(path string, info FileInfo) error {
+				movedFiles = append(movedFiles, path)
+				return nil
+			})
+		}
+		err = srcDir.Rename(srcRel, dstRel)
+	} else if err = sfs.copyFile(srcDir, srcRel, dstDir, dstRel); err == nil {
+		if err = srcDir.DeleteFile(srcRel); err != nil {
+			// The copy exists even though the source could not be removed
+			sfs.changed(dstDir, dstRel)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	sfs.changed(srcDir, srcRel)
+	sfs.changed(dstDir, dstRel)
+	for _, file := range movedFiles {
+		rel, err := filepath.Rel(filepath.Clean(srcRel), filepath.FromSlash(file))
+		if err != nil {
+			continue
+		}
+		sfs.changed(srcDir, file)
+		sfs.changed(dstDir, filepath.Join(dstRel, rel))
+	}
+	return nil
+}
+
+// Copy copies a file to a writable directory, checking the destination's limits
+func (sfs *SandboxFileSystem) Copy(srcPath, dstPath string) error {
+	srcDir, srcRel, err := sfs.resolve(srcPath, false)
+	if err != nil {
+		return err
+	}
+	dstDir, dstRel, err := sfs.resolve(dstPath, true)
+	if err != nil {
+		return err
+	}
+
+	if err := sfs.copyFile(srcDir, srcRel, dstDir, dstRel); err != nil {
+		return err
+	}
+	sfs.changed(dstDir, dstRel)
+	return nil
+}
+
+// copyFile copies a single file between (possibly identical) directories
+func (sfs *SandboxFileSystem) copyFile(srcDir *Directory, srcRel string, dstDir *Directory, dstRel string) error {
+	info, err := srcDir.Stat(srcRel)
+	if err != nil {
+		return err
+	}
+	if info.IsDir {
+		return fmt.Errorf("cannot copy directory: %s", srcRel)
+	}
+
+	data, err := srcDir.ReadBytes(srcRel, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	return dstDir.WriteBytes(dstRel, data)
+}
+
+// resolve finds the directory for a user path, optionally requiring it to be writable
+func (sfs *SandboxFileSystem) resolve(userPath string, writable bool) (*Directory, string, error) {
+	dirName, relPath, err := sfs.parsePath(userPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sfs.mu.RLock()
+	dir := sfs.directories[dirName]
+	sfs.mu.RUnlock()
+
+	if writable && dir.config.ReadOnly {
+		return nil, "", fmt.Errorf("directory '%s' is read-only", dirName)
+	}
+
+	return dir, relPath, nil
 }
 
 // ListFiles lists files in a directory
@@ -165,6 +457,17 @@ func (sfs *SandboxFileSystem) ListFiles(userPath string) ([]string, error) {
 	return dir.ListFiles(relPath)
 }
 
+// WalkFiles calls fn for every file under a directory path, e.g. './workspace/reports'.
+// Paths passed to fn are relative to the mounted directory.
+func (sfs *SandboxFileSystem) WalkFiles(userPath string, fn func(path string, info FileInfo) error) error {
+	dir, relPath, err := sfs.resolve(userPath, false)
+	if err != nil {
+		return err
+	}
+
+	return dir.WalkFiles(relPath, fn)
+}
+
 // DeleteFile deletes a file from any writable directory
 func (sfs *SandboxFileSystem) DeleteFile(userPath string) error {
 	dirName, relPath, err := sfs.parsePath(userPath)
@@ -180,21 +483,67 @@ func (sfs *SandboxFileSystem) DeleteFile(userPath string) error {
 		return fmt.Errorf("directory '%s' is read-only", dirName)
 	}
 
-	return dir.DeleteFile(relPath)
+	if err := dir.DeleteFile(relPath); err != nil {
+		return err
+	}
+	sfs.changed(dir, relPath)
+	return nil
+}
+
+// SetChangeHandler sets an optional callback invoked after a file is written, moved or deleted
+// through this filesystem. relPath is slash-separated and relative to the directory.
+func (sfs *SandboxFileSystem) SetChangeHandler(handler func(dirName, relPath string)) {
+	sfs.mu.Lock()
+	sfs.onChange = handler
+	sfs.mu.Unlock()
 }
 
-// GetDirectories returns list of available directory names
+// changed reports a modified path to the change handler
+func (sfs *SandboxFileSystem) changed(dir *Directory, relPath string) {
+	sfs.mu.RLock()
+	handler := sfs.onChange
+	sfs.mu.RUnlock()
+
+	if handler != nil {
+		handler(dir.config.Name, strings.TrimPrefix(filepath.ToSlash(filepath.Clean("/"+relPath)), "/"))
+	}
+}
+
+// GetDirectories returns list of available directory names in mount order
 func (sfs *SandboxFileSystem) GetDirectories() []string {
 	sfs.mu.RLock()
 	defer sfs.mu.RUnlock()
 
-	names := make([]string, 0, len(sfs.directories))
-	for name := range sfs.directories {
-		names = append(names, name)
-	}
+	names := make([]string, len(sfs.names))
+	copy(names, sfs.names)
 	return names
 }
 
+// GetDirectoryConfig returns the configuration of a mounted directory
+func (sfs *SandboxFileSystem) GetDirectoryConfig(name string) (DirectoryConfig, bool) {
+	sfs.mu.RLock()
+	defer sfs.mu.RUnlock()
+
+	dir, ok := sfs.directories[name]
+	if !ok {
+		return DirectoryConfig{}, false
+	}
+	return dir.config, true
+}
+
+// DirectoryWithRoot returns the name of the mounted directory stored at root, if any
+func (sfs *SandboxFileSystem) DirectoryWithRoot(root string) (string, bool) {
+	sfs.mu.RLock()
+	defer sfs.mu.RUnlock()
+
+	for _, name := range sfs.names {
+		if sfs.directories[name].config.Root == root {
+			return name, true
+		}
+	}
+	return "", false
+}
+
 // GetStats returns stats for all directories
 func (sfs *SandboxFileSystem) GetStats() map[string]Stats {
 	sfs.mu.RLock()
@@ -207,13 +556,39 @@ func (sfs *SandboxFileSystem) GetStats() map[string]Stats {
 	return stats
 }
 
-// Cleanup removes all directories
+// RemoveExecutionDirectories removes the per-execution directories of a filesystem
+// returned by ForExecution
+func (sfs *SandboxFileSystem) RemoveExecutionDirectories() error {
+	sfs.mu.Lock()
+	defer sfs.mu.Unlock()
+
+	var errs []error
+	for name, dir := range sfs.directories {
+		if dir.config.Lifecycle != LifecycleExecution {
+			continue
+		}
+		if err := dir.Cleanup(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("cleanup errors: %v", errs)
+	}
+	return nil
+}
+
+// Cleanup unmounts all directories and removes those that do not outlive the session
+// once no other filesystem mounts them. Persistent directories are left on disk.
 func (sfs *SandboxFileSystem) Cleanup() error {
 	sfs.mu.Lock()
 	defer sfs.mu.Unlock()
 
 	var errs []error
 	for name, dir := range sfs.directories {
+		if dir.unmount() > 0 || dir.config.Lifecycle == LifecyclePersistent {
+			continue
+		}
 		if err := dir.Cleanup(); err != nil {
 			errs = append(errs, fmt.Errorf("%s: %w", name, err))
 		}
@@ -227,19 +602,25 @@ func (sfs *SandboxFileSystem) Cleanup() error {
 
 // JSON handlers for host functions
 
-// HandleReadFile processes a read file request from WASM
+// HandleReadFile processes a read file request from WASM.
+// Supports ranged reads and base64 encoding for binary data.
 func (sfs *SandboxFileSystem) HandleReadFile(requestJSON []byte) []byte {
 	var req FileRequest
 	if err := json.Unmarshal(requestJSON, &req); err != nil {
 		return mustMarshal(FileResponse{Success: false, Error: "invalid request"})
 	}
 
-	content, err := sfs.ReadFile(req.Path)
+	data, err := sfs.ReadBytes(req.Path, req.Offset, req.Length)
+	if err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
+	}
+
+	encoded, err := encodeData(data, req.Encoding)
 	if err != nil {
 		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
 	}
 
-	return mustMarshal(FileResponse{Success: true, Data: content})
+	return mustMarshal(FileResponse{Success: true, Data: encoded})
 }
 
 // HandleWriteFile processes a write file request from WASM
@@ -249,7 +630,88 @@ func (sfs *SandboxFileSystem) HandleWriteFile(requestJSON []byte) []byte {
 		return mustMarshal(FileResponse{Success: false, Error: "invalid request"})
 	}
 
-	if err := sfs.WriteFile(req.Path, req.Content); err != nil {
+	data, err := decodeContent(req.Content, req.Encoding)
+	if err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
+	}
+
+	if err := sfs.WriteBytes(req.Path, data); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(FileResponse{Success: true})
+}
+
+// HandleAppendFile processes an append file request from WASM
+func (sfs *SandboxFileSystem) HandleAppendFile(requestJSON []byte) []byte {
+	var req FileRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: "invalid request"})
+	}
+
+	data, err := decodeContent(req.Content, req.Encoding)
+	if err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
+	}
+
+	if err := sfs.AppendFile(req.Path, data); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(FileResponse{Success: true})
+}
+
+// HandleStat processes a stat request from WASM
+func (sfs *SandboxFileSystem) HandleStat(requestJSON []byte) []byte {
+	var req FileRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: "invalid request"})
+	}
+
+	info, err := sfs.Stat(req.Path)
+	if err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(FileResponse{Success: true, Stat: &info})
+}
+
+// HandleMkdir processes a make directory request from WASM
+func (sfs *SandboxFileSystem) HandleMkdir(requestJSON []byte) []byte {
+	var req FileRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: "invalid request"})
+	}
+
+	if err := sfs.Mkdir(req.Path); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(FileResponse{Success: true})
+}
+
+// HandleRename processes a rename/move request from WASM
+func (sfs *SandboxFileSystem) HandleRename(requestJSON []byte) []byte {
+	var req FileRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: "invalid request"})
+	}
+
+	if err := sfs.Rename(req.Path, req.Destination); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(FileResponse{Success: true})
+}
+
+// HandleCopy processes a copy request from WASM
+func (sfs *SandboxFileSystem) HandleCopy(requestJSON []byte) []byte {
+	var req FileRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: "invalid request"})
+	}
+
+	if err := sfs.Copy(req.Path, req.Destination); err != nil {
 		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
 	}
 
@@ -301,6 +763,11 @@ func (d *Directory) validatePath(relPath string) (string, error) {
 
 	// Clean and join
 	cleanPath := filepath.Clean(relPath)
+	for _, part := range strings.Split(filepath.ToSlash(cleanPath), "/") {
+		if isReservedName(part) {
+			return "", errors.New("reserved file name")
+		}
+	}
 	fullPath := filepath.Join(d.config.Root, cleanPath)
 
 	// Ensure within directory root
@@ -313,27 +780,68 @@ func (d *Directory) validatePath(relPath string) (string, error) {
 
 // ReadFile reads a file from the directory
 func (d *Directory) ReadFile(relPath string) (string, error) {
+	content, err := d.ReadBytes(relPath, 0, 0)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// ReadBytes reads up to length bytes of a file starting at offset (length 0 reads to end of file)
+func (d *Directory) ReadBytes(relPath string, offset, length int64) ([]byte, error) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
 	fullPath, err := d.validatePath(relPath)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	if offset < 0 || length < 0 {
+		return nil, errors.New("offset and length must not be negative")
 	}
 
-	content, err := os.ReadFile(fullPath)
+	file, err := os.Open(fullPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", fmt.Errorf("file not found: %s", relPath)
+			return nil, fmt.Errorf("file not found: %s", relPath)
 		}
-		return "", fmt.Errorf("failed to read file: %w", err)
+		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	defer file.Close()
 
-	return string(content), nil
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("is a directory: %s", relPath)
+	}
+
+	if offset >= info.Size() {
+		return []byte{}, nil
+	}
+
+	remaining := info.Size() - offset
+	if length == 0 || length > remaining {
+		length = remaining
+	}
+
+	content := make([]byte, length)
+	if _, err := file.ReadAt(content, offset); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return content, nil
 }
 
 // WriteFile writes a file to the directory
 func (d *Directory) WriteFile(relPath, content string) error {
+	return d.WriteBytes(relPath, []byte(content))
+}
+
+// WriteBytes writes binary data to a file in the directory
+func (d *Directory) WriteBytes(relPath string, content []byte) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -342,6 +850,12 @@ func (d *Directory) WriteFile(relPath, content string) error {
 		return err
 	}
 
+	return d.writeBytesLocked(relPath, fullPath, content)
+}
+
+// writeBytesLocked writes a file, keeping the replaced content as a version.
+// Must be called with d.mu held.
+func (d *Directory) writeBytesLocked(relPath, fullPath string, content []byte) error {
 	contentSize := int64(len(content))
 
 	// Check file size limit
@@ -349,21 +863,19 @@ func (d *Directory) WriteFile(relPath, content string) error {
 		return fmt.Errorf("file size %d exceeds limit %d", contentSize, d.config.MaxFileSize)
 	}
 
-	// Get existing file size
-	existingSize := int64(0)
-	if info, err := os.Stat(fullPath); err == nil {
-		existingSize = info.Size()
+	existingSize, exists, err := d.existingFileSize(fullPath, relPath)
+	if err != nil {
+		return err
 	}
 
-	// Check file count limit (only for new files)
-	if existingSize == 0 && d.stats.FileCount >= d.config.MaxFiles {
-		return fmt.Errorf("file count limit reached: %d", d.config.MaxFiles)
+	if err := d.checkQuota(exists, contentSize-existingSize); err != nil {
+		return err
 	}
 
-	// Check total size limit
-	newTotal := d.stats.TotalBytes + contentSize - existingSize
-	if newTotal > d.config.MaxTotalSize {
-		return fmt.Errorf("total size limit would be exceeded: %d > %d", newTotal, d.config.MaxTotalSize)
+	if exists {
+		if err := d.saveVersion(relPath, fullPath, existingSize, contentSize-existingSize); err != nil {
+			return err
+		}
 	}
 
 	// Create parent directories
@@ -372,23 +884,232 @@ func (d *Directory) WriteFile(relPath, content string) error {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// Write file
-	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+	// Write file atomically so readers and crashes never observe partial content
+	if err := writeFileAtomic(fullPath, content); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
-	// Update stats
-	if existingSize == 0 {
-		d.stats.FileCount++
+	d.updateStats(exists, contentSize-existingSize, contentSize)
+	d.trimHistory()
+	return nil
+}
+
+// AppendFile appends data to a file in the directory, creating it if needed
+func (d *Directory) AppendFile(relPath string, content []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	fullPath, err := d.validatePath(relPath)
+	if err != nil {
+		return err
 	}
-	d.stats.TotalBytes += contentSize - existingSize
-	if contentSize > d.stats.MaxFileSize {
-		d.stats.MaxFileSize = contentSize
+
+	existingSize, exists, err := d.existingFileSize(fullPath, relPath)
+	if err != nil {
+		return err
+	}
+
+	newSize := existingSize + int64(len(content))
+	if newSize > d.config.MaxFileSize {
+		return fmt.Errorf("file size %d exceeds limit %d", newSize, d.config.MaxFileSize)
+	}
+
+	if err := d.checkQuota(exists, int64(len(content))); err != nil {
+		return err
+	}
+
+	// Create parent directories
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.OpenFile(fullPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to append to file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to append to file: %w", err)
+	}
+
+	d.updateStats(exists, int64(len(content)), newSize)
+	d.trimHistory()
+	return nil
+}
+
+// Stat returns information about a file or directory
+func (d *Directory) Stat(relPath string) (FileInfo, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	fullPath, err := d.validatePath(relPath)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return FileInfo{}, fmt.Errorf("file not found: %s", relPath)
+		}
+		return FileInfo{}, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return FileInfo{
+		Name:    info.Name(),
+		Size:    info.Size(),
+		ModTime: info.ModTime().UnixMilli(),
+		IsDir:   info.IsDir(),
+	}, nil
+}
+
+// Mkdir creates a directory and any missing parents
+func (d *Directory) Mkdir(relPath string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	fullPath, err := d.validatePath(relPath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(fullPath, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
 	return nil
 }
 
+// Rename moves a file or directory within the directory, replacing an existing destination file
+func (d *Directory) Rename(srcRel, dstRel string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	srcPath, err := d.validatePath(srcRel)
+	if err != nil {
+		return err
+	}
+	dstPath, err := d.validatePath(dstRel)
+	if err != nil {
+		return err
+	}
+	if srcPath == d.config.Root || dstPath == d.config.Root {
+		return errors.New("cannot rename the directory root")
+	}
+
+	srcInfo, err := os.Stat(srcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("file not found: %s", srcRel)
+		}
+		return err
+	}
+	if srcPath == dstPath {
+		return nil
+	}
+
+	// A replaced destination file no longer counts towards the limits. The destination
+	// may also be the source under another name, e.g. on a case-insensitive file system.
+	var replaced os.FileInfo
+	if info, err := os.Stat(dstPath); err == nil && !os.SameFile(srcInfo, info) {
+		if info.IsDir() {
+			return fmt.Errorf("destination is a directory: %s", dstRel)
+		}
+		replaced = info
+	}
+
+	if replaced != nil {
+		if err := d.saveVersion(dstRel, dstPath, replaced.Size(), -replaced.Size()); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := os.Rename(srcPath, dstPath); err != nil {
+		return fmt.Errorf("failed to rename: %w", err)
+	}
+
+	if replaced != nil {
+		d.stats.FileCount--
+		d.stats.TotalBytes -= replaced.Size()
+		d.reconcileIfDrifted()
+	}
+
+	return nil
+}
+
+// existingFileSize returns the size of the file at fullPath and whether it exists
+func (d *Directory) existingFileSize(fullPath, relPath string) (int64, bool, error) {
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.IsDir() {
+		return 0, false, fmt.Errorf("is a directory: %s", relPath)
+	}
+	return info.Size(), true, nil
+}
+
+// checkQuota checks the file count and total size limits for a write that changes
+// the directory size by delta bytes. Before rejecting a write, stats are reconciled
+// from disk in case they drifted (e.g., files changed outside the sandbox).
+func (d *Directory) checkQuota(exists bool, delta int64) error {
+	if err := d.quotaError(exists, delta); err == nil {
+		return nil
+	}
+
+	if err := d.recalculateStats(); err != nil {
+		return fmt.Errorf("failed to reconcile stats: %w", err)
+	}
+	return d.quotaError(exists, delta)
+}
+
+// quotaError returns an error if a write would exceed the directory limits
+func (d *Directory) quotaError(exists bool, delta int64) error {
+	// Check file count limit (only for new files)
+	if !exists && d.stats.FileCount >= d.config.MaxFiles {
+		return fmt.Errorf("file count limit reached: %d", d.config.MaxFiles)
+	}
+
+	// Check total size limit
+	newTotal := d.stats.TotalBytes + delta
+	if delta > 0 && newTotal > d.config.MaxTotalSize {
+		return fmt.Errorf("total size limit would be exceeded: %d > %d", newTotal, d.config.MaxTotalSize)
+	}
+
+	return nil
+}
+
+// updateStats records a completed write
+func (d *Directory) updateStats(existed bool, delta, fileSize int64) {
+	if !existed {
+		d.stats.FileCount++
+	}
+	d.stats.TotalBytes += delta
+	if fileSize > d.stats.MaxFileSize {
+		d.stats.MaxFileSize = fileSize
+	}
+	d.reconcileIfDrifted()
+}
+
+// reconcileIfDrifted recalculates stats from disk if they are impossible,
+// which means files were changed or removed without going through the directory
+func (d *Directory) reconcileIfDrifted() {
+	if d.stats.FileCount < 0 || d.stats.TotalBytes < 0 || (d.stats.FileCount == 0 && d.stats.TotalBytes != 0) {
+		// Best effort: on failure, stats are reconciled again on the next quota rejection
+		_ = d.recalculateStats()
+	}
+}
+
 // ListFiles lists files in a directory
 func (d *Directory) ListFiles(relPath string) ([]string, error) {
 	d.mu.RLock()
@@ -410,6 +1131,9 @@ func (d *Directory) ListFiles(relPath string) ([]string, error) {
 	files := make([]string, 0, len(entries))
 	for _, entry := range entries {
 		name := entry.Name()
+		if isReservedName(name) {
+			continue
+		}
 		if entry.IsDir() {
 			name += "/"
 		}
@@ -419,6 +1143,46 @@ func (d *Directory) ListFiles(relPath string) ([]string, error) {
 	return files, nil
 }
 
+// WalkFiles calls fn for every file under relPath, skipping internal files.
+// Paths passed to fn are slash-separated and relative to the directory root.
+func (d *Directory) WalkFiles(relPath string, fn func(path string, info FileInfo) error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	fullPath, err := d.validatePath(relPath)
+	if err != nil {
+		return err
+	}
+
+	return filepath.Walk(fullPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == fullPath && os.IsNotExist(err) {
+				return fmt.Errorf("directory not found: %s", relPath)
+			}
+			return err
+		}
+		if path != fullPath && isReservedName(info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(d.config.Root, path)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), FileInfo{
+			Name:    info.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime().UnixMilli(),
+		})
+	})
+}
+
 // DeleteFile deletes a file from the directory
 func (d *Directory) DeleteFile(relPath string) error {
 	d.mu.Lock()
@@ -437,17 +1201,31 @@ func (d *Directory) DeleteFile(relPath string) error {
 		return err
 	}
 
+	if !info.IsDir() {
+		if err := d.saveVersion(relPath, fullPath, info.Size(), -info.Size()); err != nil {
+			return err
+		}
+	}
+
 	if err := os.Remove(fullPath); err != nil {
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
 
-	// Update stats
-	d.stats.FileCount--
-	d.stats.TotalBytes -= info.Size()
+	// Update stats (only empty directories can be removed, so they hold no files)
+	if !info.IsDir() {
+		d.stats.FileCount--
+		d.stats.TotalBytes -= info.Size()
+		d.reconcileIfDrifted()
+	}
 
 	return nil
 }
 
+// Config returns the directory configuration
+func (d *Directory) Config() DirectoryConfig {
+	return d.config
+}
+
 // GetStats returns current directory statistics
 func (d *Directory) GetStats() Stats {
 	d.mu.RLock()
@@ -465,6 +1243,12 @@ func (d *Directory) recalculateStats() error {
 		if err != nil {
 			return err
 		}
+		if path != d.config.Root && isReservedName(info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		if !info.IsDir() {
 			fileCount++
 			totalBytes += info.Size()
@@ -482,6 +1266,7 @@ func (d *Directory) recalculateStats() error {
 	d.stats.TotalBytes = totalBytes
 	d.stats.FileCount = fileCount
 	d.stats.MaxFileSize = maxFileSize
+	d.stats.VersionBytes = d.versionBytesOnDisk()
 
 	return nil
 }
@@ -499,6 +1284,111 @@ func (d *Directory) Cleanup() error {
 	return nil
 }
 
+// unmount records that a filesystem no longer mounts the directory and returns
+// the number of filesystems still mounting it
+func (d *Directory) unmount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.mounts > 0 {
+		d.mounts--
+	}
+	return d.mounts
+}
+
+// Mounted reports whether any filesystem still mounts the directory
+func (d *Directory) Mounted() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.mounts > 0
+}
+
+// writeFileAtomic writes content to a temp file in the target directory and renames it into place
+func writeFileAtomic(fullPath string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), tempFilePrefix+"*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, fullPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// isReservedName reports whether a file name is reserved for internal use
+func isReservedName(name string) bool {
+	return strings.HasPrefix(name, reservedPrefix)
+}
+
+// isTempFile reports whether a file name belongs to an in-progress atomic write
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, tempFilePrefix)
+}
+
+// removeStaleTempFiles removes temp files left behind by writes interrupted by a crash
+func (d *Directory) removeStaleTempFiles() {
+	cutoff := time.Now().Add(-staleTempFileAge)
+	filepath.Walk(d.config.Root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() && isTempFile(info.Name()) && info.ModTime().Before(cutoff) {
+			os.Remove(path)
+		}
+		return nil
+	})
+}
+
+// decodeContent converts request content to bytes according to its encoding
+func decodeContent(content, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", EncodingUTF8:
+		return []byte(content), nil
+	case EncodingBase64:
+		data, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 content: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", encoding)
+	}
+}
+
+// encodeData converts file data to a response string according to the requested encoding
+func encodeData(data []byte, encoding string) (string, error) {
+	switch encoding {
+	case "", EncodingUTF8:
+		return string(data), nil
+	case EncodingBase64:
+		return base64.StdEncoding.EncodeToString(data), nil
+	default:
+		return "", fmt.Errorf("unsupported encoding: %s", encoding)
+	}
+}
+
 // mustMarshal marshals a value to JSON, ignoring errors
 func mustMarshal(v interface{}) []byte {
 	data, _ := json.Marshal(v)
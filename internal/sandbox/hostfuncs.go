@@ -3,9 +3,10 @@ package sandbox
 import (
 	"context"
 	"encoding/json"
-	"github.com/modelcontextprotocol/go-sdk/mcp"
 
 	extism "github.com/extism/go-sdk"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yousuf/runbyte/internal/client"
 )
 
 // McpToolCall represents a call to an MCP tool from the sandbox
@@ -13,15 +14,20 @@ type McpToolCall struct {
 	ServerName string                 `json:"serverName"`
 	ToolName   string                 `json:"toolName"`
 	Args       map[string]interface{} `json:"args"`
+	Cache      *bool                  `json:"cache,omitempty"` // false bypasses the tool result cache
 }
 
-// McpToolResponse represents the response from an MCP tool call
+// McpToolResponse represents the response from an MCP tool call.
+// Submitting a call returns its handle; the result is collected later with mcp_poll.
 type McpToolResponse struct {
-	Result string `json:"result"`
-	Error  string `json:"error"`
+	Handle  int64             `json:"handle,omitempty"`
+	Result  string            `json:"result"`
+	Error   string            `json:"error"`
+	Results []McpToolResponse `json:"results,omitempty"` // Per-call results of a batch, in call order
 }
 
-// createCallMcpToolHostFunc creates the host function for calling MCP tools
+// createCallMcpToolHostFunc creates the host function submitting MCP tool calls.
+// Calls run concurrently in the background; the sandbox collects results through mcp_poll.
 func createCallMcpToolHostFunc(sb *Sandbox) extism.HostFunction {
 	return extism.NewHostFunctionWithStack(
 		"callMcpTool",
@@ -45,45 +51,8 @@ func createCallMcpToolHostFunc(sb *Sandbox) extism.HostFunction {
 
 			plugin.Logf(extism.LogLevelInfo, "Calling MCP tool: %s.%s", toolCall.ServerName, toolCall.ToolName)
 
-			// Make synchronous MCP call
-			result, err := sb.clientHub.CallTool(sb.ctx, toolCall.ServerName, toolCall.ToolName, toolCall.Args)
-			if err != nil {
-				plugin.Logf(extism.LogLevelError, "Failed to call MCP tool: %v", err)
-
-				errResp := map[string]string{"error": err.Error()}
-				responseData, _ := json.Marshal(errResp)
-				responseOffset, err := plugin.WriteBytes(responseData)
-				if err != nil {
-					plugin.Logf(extism.LogLevelError, "Failed to write error response: %v", err)
-					stack[0] = 0
-					return
-				}
-
-				stack[0] = responseOffset
-				return
-			}
-
-			// Prepare response
-			response := McpToolResponse{}
-
-			if err != nil {
-				response.Error = err.Error()
-				plugin.Logf(extism.LogLevelError, "MCP call failed: %v", err)
-			} else if result.IsError {
-				errMsg := getTextContent(result.Content)
-				response.Error = errMsg
-
-				plugin.Logf(extism.LogLevelInfo, "MCP call returned an error: %s", errMsg)
-			} else {
-				if result.StructuredContent != nil {
-					structured, _ := json.Marshal(result.StructuredContent)
-					response.Result = string(structured)
-				} else {
-					response.Result = getTextContent(result.Content)
-				}
-
-				plugin.Log(extism.LogLevelInfo, "MCP call succeeded")
-			}
+			// Start the call and return its handle
+			response := McpToolResponse{Handle: sb.calls.Submit(toolCall)}
 
 			// Write response back to plugin memory
 			responseData, _ := json.Marshal(response)
@@ -98,10 +67,31 @@ func createCallMcpToolHostFunc(sb *Sandbox) extism.HostFunction {
 			stack[0] = responseOffset
 		},
 		[]extism.ValueType{extism.ValueTypeI64}, // input: offset to tool call JSON
-		[]extism.ValueType{extism.ValueTypeI64}, // output: offset to result JSON
+		[]extism.ValueType{extism.ValueTypeI64}, // output: offset to handle JSON
 	)
 }
 
+// executeToolCall calls an MCP tool and converts the result for the sandbox
+func executeToolCall(ctx context.Context, clientHub *client.McpClientHub, toolCall McpToolCall) McpToolResponse {
+	if toolCall.Cache != nil && !*toolCall.Cache {
+		ctx = client.WithoutCache(ctx)
+	}
+
+	result, err := clientHub.CallTool(ctx, toolCall.ServerName, toolCall.ToolName, toolCall.Args)
+	if err != nil {
+		return McpToolResponse{Error: err.Error()}
+	}
+
+	if result.IsError {
+		return McpToolResponse{Error: getTextContent(result.Content)}
+	}
+	if result.StructuredContent != nil {
+		structured, _ := json.Marshal(result.StructuredContent)
+		return McpToolResponse{Result: string(structured)}
+	}
+	return McpToolResponse{Result: getTextContent(result.Content)}
+}
+
 func getTextContent(contentList []mcp.Content) string {
 	for _, content := range contentList {
 		if textContent, ok := content.(*mcp.TextContent); ok {
@@ -7,20 +7,33 @@ import (
 )
 
 // TODO: rename to createFileSystemHostFunctions
-// createWorkspaceHostFunctions creates all filesystem-related host functions
-func createWorkspaceHostFunctions(sfs *SandboxFileSystem) []extism.HostFunction {
+// createWorkspaceHostFunctions creates all filesystem-related host functions.
+// Path locks taken through the lock functions are tracked by the execution's LockHolder.
+func createWorkspaceHostFunctions(sfs *SandboxFileSystem, locks *LockHolder) []extism.HostFunction {
 	return []extism.HostFunction{
-		createReadFileHostFunc(sfs),
-		createWriteFileHostFunc(sfs),
-		createListFilesHostFunc(sfs),
-		createDeleteFileHostFunc(sfs),
+		createJSONHostFunc("workspace_readFile", "Reading file from sandbox filesystem", sfs.HandleReadFile),
+		createJSONHostFunc("workspace_writeFile", "Writing file to sandbox filesystem", sfs.HandleWriteFile),
+		createJSONHostFunc("workspace_appendFile", "Appending to file in sandbox filesystem", sfs.HandleAppendFile),
+		createJSONHostFunc("workspace_listFiles", "Listing files in sandbox filesystem", sfs.HandleListFiles),
+		createJSONHostFunc("workspace_deleteFile", "Deleting file from sandbox filesystem", sfs.HandleDeleteFile),
+		createJSONHostFunc("workspace_stat", "Getting file info from sandbox filesystem", sfs.HandleStat),
+		createJSONHostFunc("workspace_mkdir", "Creating directory in sandbox filesystem", sfs.HandleMkdir),
+		createJSONHostFunc("workspace_rename", "Renaming file in sandbox filesystem", sfs.HandleRename),
+		createJSONHostFunc("workspace_copy", "Copying file in sandbox filesystem", sfs.HandleCopy),
+		createJSONHostFunc("workspace_glob", "Finding files in sandbox filesystem", sfs.HandleGlob),
+		createJSONHostFunc("workspace_grep", "Searching file contents in sandbox filesystem", sfs.HandleGrep),
+		createJSONHostFunc("workspace_listVersions", "Listing file versions in sandbox filesystem", sfs.HandleListVersions),
+		createJSONHostFunc("workspace_restore", "Restoring file version in sandbox filesystem", sfs.HandleRestoreVersion),
+		createJSONHostFunc("workspace_lock", "Acquiring path lock in sandbox filesystem", locks.HandleLock),
+		createJSONHostFunc("workspace_unlock", "Releasing path lock in sandbox filesystem", locks.HandleUnlock),
 	}
 }
 
-// createReadFileHostFunc creates the host function for reading files
-func createReadFileHostFunc(sfs *SandboxFileSystem) extism.HostFunction {
+// createJSONHostFunc creates a host function that passes a JSON request from WASM
+// to a handler and writes back its JSON response. Each call is logged with logMessage, if set.
+func createJSONHostFunc(name, logMessage string, handler func(requestJSON []byte) []byte) extism.HostFunction {
 	return extism.NewHostFunctionWithStack(
-		"workspace_readFile",
+		name,
 		func(ctx context.Context, plugin *extism.CurrentPlugin, stack []uint64) {
 			offset := stack[0]
 			inputData, err := plugin.ReadBytes(offset)
@@ -30,109 +43,12 @@ func createReadFileHostFunc(sfs *SandboxFileSystem) extism.HostFunction {
 				return
 			}
 
-			plugin.Log(extism.LogLevelDebug, "Reading file from sandbox filesystem")
-
-			// Delegate to SandboxFileSystem
-			responseData := sfs.HandleReadFile(inputData)
-
-			// Write response
-			responseOffset, err := plugin.WriteBytes(responseData)
-			if err != nil {
-				plugin.Logf(extism.LogLevelError, "Failed to write response: %v", err)
-				stack[0] = 0
-				return
-			}
-
-			stack[0] = responseOffset
-		},
-		[]extism.ValueType{extism.ValueTypeI64},
-		[]extism.ValueType{extism.ValueTypeI64},
-	)
-}
-
-// createWriteFileHostFunc creates the host function for writing files
-func createWriteFileHostFunc(sfs *SandboxFileSystem) extism.HostFunction {
-	return extism.NewHostFunctionWithStack(
-		"workspace_writeFile",
-		func(ctx context.Context, plugin *extism.CurrentPlugin, stack []uint64) {
-			offset := stack[0]
-			inputData, err := plugin.ReadBytes(offset)
-			if err != nil {
-				plugin.Logf(extism.LogLevelError, "Failed to read input: %v", err)
-				stack[0] = 0
-				return
-			}
-
-			plugin.Log(extism.LogLevelDebug, "Writing file to sandbox filesystem")
-
-			// Delegate to SandboxFileSystem
-			responseData := sfs.HandleWriteFile(inputData)
-
-			// Write response
-			responseOffset, err := plugin.WriteBytes(responseData)
-			if err != nil {
-				plugin.Logf(extism.LogLevelError, "Failed to write response: %v", err)
-				stack[0] = 0
-				return
-			}
-
-			stack[0] = responseOffset
-		},
-		[]extism.ValueType{extism.ValueTypeI64},
-		[]extism.ValueType{extism.ValueTypeI64},
-	)
-}
-
-// createListFilesHostFunc creates the host function for listing files
-func createListFilesHostFunc(sfs *SandboxFileSystem) extism.HostFunction {
-	return extism.NewHostFunctionWithStack(
-		"workspace_listFiles",
-		func(ctx context.Context, plugin *extism.CurrentPlugin, stack []uint64) {
-			offset := stack[0]
-			inputData, err := plugin.ReadBytes(offset)
-			if err != nil {
-				plugin.Logf(extism.LogLevelError, "Failed to read input: %v", err)
-				stack[0] = 0
-				return
-			}
-
-			plugin.Log(extism.LogLevelDebug, "Listing files in sandbox filesystem")
-
-			// Delegate to SandboxFileSystem
-			responseData := sfs.HandleListFiles(inputData)
-
-			// Write response
-			responseOffset, err := plugin.WriteBytes(responseData)
-			if err != nil {
-				plugin.Logf(extism.LogLevelError, "Failed to write response: %v", err)
-				stack[0] = 0
-				return
-			}
-
-			stack[0] = responseOffset
-		},
-		[]extism.ValueType{extism.ValueTypeI64},
-		[]extism.ValueType{extism.ValueTypeI64},
-	)
-}
-
-// createDeleteFileHostFunc creates the host function for deleting files
-func createDeleteFileHostFunc(sfs *SandboxFileSystem) extism.HostFunction {
-	return extism.NewHostFunctionWithStack(
-		"workspace_deleteFile",
-		func(ctx context.Context, plugin *extism.CurrentPlugin, stack []uint64) {
-			offset := stack[0]
-			inputData, err := plugin.ReadBytes(offset)
-			if err != nil {
-				plugin.Logf(extism.LogLevelError, "Failed to read input: %v", err)
-				stack[0] = 0
-				return
+			if logMessage != "" {
+				plugin.Log(extism.LogLevelDebug, logMessage)
 			}
 
-			plugin.Log(extism.LogLevelDebug, "Deleting file from sandbox filesystem")
-
-			// Delegate to SandboxFileSystem
-			responseData := sfs.HandleDeleteFile(inputData)
+			// Delegate to handler
+			responseData := handler(inputData)
 
 			// Write response
 			responseOffset, err := plugin.WriteBytes(responseData)
@@ -0,0 +1,17 @@
+package sandbox
+
+import (
+	extism "github.com/extism/go-sdk"
+)
+
+// createKVHostFunctions creates the key-value store host functions.
+// They are always registered so the plugin links; with a nil store every call returns an error.
+func createKVHostFunctions(kv *KVStore) []extism.HostFunction {
+	return []extism.HostFunction{
+		createJSONHostFunc("kv_get", "Reading key from key-value store", kv.HandleGet),
+		createJSONHostFunc("kv_set", "Writing key to key-value store", kv.HandleSet),
+		createJSONHostFunc("kv_delete", "Deleting key from key-value store", kv.HandleDelete),
+		createJSONHostFunc("kv_list", "Listing keys in key-value store", kv.HandleList),
+		createJSONHostFunc("kv_increment", "Incrementing key in key-value store", kv.HandleIncrement),
+	}
+}
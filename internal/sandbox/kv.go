@@ -0,0 +1,389 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KVFileName is the file holding a workspace's key-value store, hidden from the filesystem API
+const KVFileName = reservedPrefix + "kv.json"
+
+// maxKVKeyLength limits the length of a single key
+const maxKVKeyLength = 1024
+
+// errKVUnavailable is returned when no key-value store is attached to the sandbox
+var errKVUnavailable = errors.New("key-value store is not available")
+
+// KVLimits defines quotas for a key-value store
+type KVLimits struct {
+	MaxKeys      int
+	MaxValueSize int64 // Maximum size of a single JSON-encoded value
+	MaxTotalSize int64 // Maximum size of all keys and values
+}
+
+// KVStore is a small persistent key-value store with optional per-key expiry.
+// Values are JSON documents. The whole store is rewritten atomically on every change.
+type KVStore struct {
+	path       string
+	limits     KVLimits
+	entries    map[string]kvEntry
+	totalBytes int64
+	mu         sync.Mutex
+}
+
+// kvEntry is a stored value
+type kvEntry struct {
+	Value     json.RawMessage `json:"value"`
+	ExpiresAt int64           `json:"expiresAt,omitempty"` // Unix milliseconds (0 = never)
+}
+
+// KVEntry is a key with its value, as returned by List
+type KVEntry struct {
+	Key       string          `json:"key"`
+	Value     json.RawMessage `json:"value"`
+	ExpiresAt int64           `json:"expiresAt,omitempty"`
+}
+
+// KVStats tracks key-value store usage
+type KVStats struct {
+	Keys       int
+	TotalBytes int64
+}
+
+// KVRequest represents a key-value operation request from WASM
+type KVRequest struct {
+	Key    string          `json:"key,omitempty"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	TtlMs  int64           `json:"ttlMs,omitempty"`  // Time to live for set and increment (0 = no expiry)
+	Prefix string          `json:"prefix,omitempty"` // Key prefix for list
+	Limit  int             `json:"limit,omitempty"`  // Maximum entries for list (0 = all)
+	Delta  *float64        `json:"delta,omitempty"`  // Amount to add for increment (default 1)
+}
+
+// KVResponse represents a key-value operation response
+type KVResponse struct {
+	Success bool            `json:"success"`
+	Found   bool            `json:"found,omitempty"`
+	Value   json.RawMessage `json:"value,omitempty"`
+	Entries []KVEntry       `json:"entries,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// NewKVStore opens the key-value store persisted at path, creating it on first write
+func NewKVStore(path string, limits KVLimits) (*KVStore, error) {
+	s := &KVStore{
+		path:    path,
+		limits:  limits,
+		entries: make(map[string]kvEntry),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read key-value store: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.entries); err != nil {
+			return nil, fmt.Errorf("failed to parse key-value store %s: %w", path, err)
+		}
+	}
+
+	for key, entry := range s.entries {
+		s.totalBytes += entrySize(key, entry.Value)
+	}
+	s.removeExpired(time.Now())
+
+	return s, nil
+}
+
+// Get returns the value for a key, or false if it is missing or expired
+func (s *KVStore) Get(key string) (json.RawMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || entry.expired(time.Now()) {
+		return nil, false
+	}
+	return entry.Value, true
+}
+
+// Set stores a JSON value under a key. A positive ttl makes the key expire.
+func (s *KVStore) Set(key string, value json.RawMessage, ttl time.Duration) error {
+	if !json.Valid(value) {
+		return errors.New("value must be valid JSON")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.removeExpired(now)
+
+	return s.put(key, kvEntry{Value: value, ExpiresAt: expiresAt(now, ttl)})
+}
+
+// Delete removes a key, reporting whether it existed
+func (s *KVStore) Delete(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.removeExpired(now)
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return false, nil
+	}
+
+	delete(s.entries, key)
+	s.totalBytes -= entrySize(key, entry.Value)
+
+	if err := s.save(); err != nil {
+		s.entries[key] = entry
+		s.totalBytes += entrySize(key, entry.Value)
+		return false, err
+	}
+	return true, nil
+}
+
+// List returns unexpired entries whose keys start with prefix, sorted by key.
+// A positive limit caps the number of entries returned.
+func (s *KVStore) List(prefix string, limit int) []KVEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entries := make([]KVEntry, 0)
+	for key, entry := range s.entries {
+		if strings.HasPrefix(key, prefix) && !entry.expired(now) {
+			entries = append(entries, KVEntry{Key: key, Value: entry.Value, ExpiresAt: entry.ExpiresAt})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries
+}
+
+// Increment atomically adds delta to a numeric value, treating a missing key as 0.
+// A positive ttl sets a new expiry; otherwise an existing expiry is kept.
+func (s *KVStore) Increment(key string, delta float64, ttl time.Duration) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.removeExpired(now)
+
+	var current float64
+	var expiry int64
+	if entry, ok := s.entries[key]; ok {
+		if err := json.Unmarshal(entry.Value, &current); err != nil {
+			return 0, fmt.Errorf("value of %q is not a number", key)
+		}
+		expiry = entry.ExpiresAt
+	}
+	if ttl > 0 {
+		expiry = expiresAt(now, ttl)
+	}
+
+	next := current + delta
+	value := json.RawMessage(strconv.FormatFloat(next, 'g', -1, 64))
+	if err := s.put(key, kvEntry{Value: value, ExpiresAt: expiry}); err != nil {
+		return 0, err
+	}
+
+	return next, nil
+}
+
+// GetStats returns current store statistics
+func (s *KVStore) GetStats() KVStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return KVStats{Keys: len(s.entries), TotalBytes: s.totalBytes}
+}
+
+// HandleGet processes a get request from WASM
+func (s *KVStore) HandleGet(requestJSON []byte) []byte {
+	if s == nil {
+		return mustMarshal(KVResponse{Success: false, Error: errKVUnavailable.Error()})
+	}
+
+	var req KVRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(KVResponse{Success: false, Error: "invalid request"})
+	}
+
+	value, found := s.Get(req.Key)
+	return mustMarshal(KVResponse{Success: true, Found: found, Value: value})
+}
+
+// HandleSet processes a set request from WASM
+func (s *KVStore) HandleSet(requestJSON []byte) []byte {
+	if s == nil {
+		return mustMarshal(KVResponse{Success: false, Error: errKVUnavailable.Error()})
+	}
+
+	var req KVRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(KVResponse{Success: false, Error: "invalid request"})
+	}
+
+	if err := s.Set(req.Key, req.Value, time.Duration(req.TtlMs)*time.Millisecond); err != nil {
+		return mustMarshal(KVResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(KVResponse{Success: true})
+}
+
+// HandleDelete processes a delete request from WASM
+func (s *KVStore) HandleDelete(requestJSON []byte) []byte {
+	if s == nil {
+		return mustMarshal(KVResponse{Success: false, Error: errKVUnavailable.Error()})
+	}
+
+	var req KVRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(KVResponse{Success: false, Error: "invalid request"})
+	}
+
+	found, err := s.Delete(req.Key)
+	if err != nil {
+		return mustMarshal(KVResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(KVResponse{Success: true, Found: found})
+}
+
+// HandleList processes a list request from WASM
+func (s *KVStore) HandleList(requestJSON []byte) []byte {
+	if s == nil {
+		return mustMarshal(KVResponse{Success: false, Error: errKVUnavailable.Error()})
+	}
+
+	var req KVRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(KVResponse{Success: false, Error: "invalid request"})
+	}
+
+	return mustMarshal(KVResponse{Success: true, Entries: s.List(req.Prefix, req.Limit)})
+}
+
+// HandleIncrement processes an increment request from WASM
+func (s *KVStore) HandleIncrement(requestJSON []byte) []byte {
+	if s == nil {
+		return mustMarshal(KVResponse{Success: false, Error: errKVUnavailable.Error()})
+	}
+
+	var req KVRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(KVResponse{Success: false, Error: "invalid request"})
+	}
+
+	delta := 1.0
+	if req.Delta != nil {
+		delta = *req.Delta
+	}
+
+	value, err := s.Increment(req.Key, delta, time.Duration(req.TtlMs)*time.Millisecond)
+	if err != nil {
+		return mustMarshal(KVResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(KVResponse{Success: true, Found: true, Value: mustMarshal(value)})
+}
+
+// put stores an entry after checking key and quotas, then persists the store.
+// Must be called with s.mu held.
+func (s *KVStore) put(key string, entry kvEntry) error {
+	if key == "" {
+		return errors.New("key must not be empty")
+	}
+	if len(key) > maxKVKeyLength {
+		return fmt.Errorf("key exceeds %d characters", maxKVKeyLength)
+	}
+	if s.limits.MaxValueSize > 0 && int64(len(entry.Value)) > s.limits.MaxValueSize {
+		return fmt.Errorf("value size %d exceeds limit %d", len(entry.Value), s.limits.MaxValueSize)
+	}
+
+	old, exists := s.entries[key]
+	delta := entrySize(key, entry.Value)
+	if exists {
+		delta -= entrySize(key, old.Value)
+	}
+
+	if !exists && s.limits.MaxKeys > 0 && len(s.entries) >= s.limits.MaxKeys {
+		return fmt.Errorf("key limit reached (%d)", s.limits.MaxKeys)
+	}
+	if s.limits.MaxTotalSize > 0 && s.totalBytes+delta > s.limits.MaxTotalSize {
+		return fmt.Errorf("key-value store size limit exceeded (%d bytes)", s.limits.MaxTotalSize)
+	}
+
+	s.entries[key] = entry
+	s.totalBytes += delta
+
+	if err := s.save(); err != nil {
+		// Roll back so memory matches disk
+		if exists {
+			s.entries[key] = old
+		} else {
+			delete(s.entries, key)
+		}
+		s.totalBytes -= delta
+		return err
+	}
+
+	return nil
+}
+
+// save writes the store to disk. Must be called with s.mu held.
+func (s *KVStore) save() error {
+	data, err := json.Marshal(s.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode key-value store: %w", err)
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("failed to write key-value store: %w", err)
+	}
+	return nil
+}
+
+// removeExpired drops expired entries from memory; they are removed from disk on the next save.
+// Must be called with s.mu held (or before the store is shared).
+func (s *KVStore) removeExpired(now time.Time) {
+	for key, entry := range s.entries {
+		if entry.expired(now) {
+			delete(s.entries, key)
+			s.totalBytes -= entrySize(key, entry.Value)
+		}
+	}
+}
+
+// expired reports whether the entry has expired at now
+func (e kvEntry) expired(now time.Time) bool {
+	return e.ExpiresAt > 0 && now.UnixMilli() >= e.ExpiresAt
+}
+
+// expiresAt converts a ttl into an absolute expiry (0 = never)
+func expiresAt(now time.Time, ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return now.Add(ttl).UnixMilli()
+}
+
+// entrySize returns the bytes a key and value count against the store quota
+func entrySize(key string, value json.RawMessage) int64 {
+	return int64(len(key) + len(value))
+}
@@ -0,0 +1,202 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Lock wait limits for withLock
+const (
+	defaultLockTimeout = 10 * time.Second
+	maxLockTimeout     = 60 * time.Second
+)
+
+// LockRequest represents an advisory lock request from WASM
+type LockRequest struct {
+	Path      string `json:"path"`
+	TimeoutMs int64  `json:"timeoutMs,omitempty"` // Maximum time to wait for the lock (default 10s)
+}
+
+// LockHolder tracks the advisory path locks taken by a single code execution.
+// Locks live on the Directory, so they are shared by every session mounting it.
+// Locks still held when the execution ends are released by ReleaseAll.
+type LockHolder struct {
+	ctx  context.Context // Execution context; waiting for a lock stops when it is cancelled
+	sfs  *SandboxFileSystem
+	held map[string]heldLock // Key: directory name + cleaned relative path
+	mu   sync.Mutex
+}
+
+// heldLock identifies a lock taken by a LockHolder
+type heldLock struct {
+	dir     *Directory
+	relPath string
+}
+
+// NewLockHolder creates a lock holder for one code execution
+func (sfs *SandboxFileSystem) NewLockHolder(ctx context.Context) *LockHolder {
+	return &LockHolder{
+		ctx:  ctx,
+		sfs:  sfs,
+		held: make(map[string]heldLock),
+	}
+}
+
+// Lock acquires the advisory lock for a path, waiting up to timeout
+func (h *LockHolder) Lock(userPath string, timeout time.Duration) error {
+	dirName, relPath, err := h.sfs.parsePath(userPath)
+	if err != nil {
+		return err
+	}
+	relPath = filepath.Clean("/" + relPath)
+	key := dirName + relPath
+
+	h.mu.Lock()
+	_, alreadyHeld := h.held[key]
+	h.mu.Unlock()
+	if alreadyHeld {
+		// Waiting would deadlock on our own lock
+		return fmt.Errorf("lock already held by this execution: %s", userPath)
+	}
+
+	h.sfs.mu.RLock()
+	dir := h.sfs.directories[dirName]
+	h.sfs.mu.RUnlock()
+
+	if err := dir.acquireLock(h.ctx, relPath, timeout); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", userPath, err)
+	}
+
+	h.mu.Lock()
+	h.held[key] = heldLock{dir: dir, relPath: relPath}
+	h.mu.Unlock()
+
+	return nil
+}
+
+// Unlock releases a lock taken by this holder
+func (h *LockHolder) Unlock(userPath string) error {
+	dirName, relPath, err := h.sfs.parsePath(userPath)
+	if err != nil {
+		return err
+	}
+	key := dirName + filepath.Clean("/"+relPath)
+
+	h.mu.Lock()
+	lock, ok := h.held[key]
+	delete(h.held, key)
+	h.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("lock not held: %s", userPath)
+	}
+
+	lock.dir.releaseLock(lock.relPath)
+	return nil
+}
+
+// ReleaseAll releases every lock still held, e.g. when execution ends without unlocking
+func (h *LockHolder) ReleaseAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key, lock := range h.held {
+		lock.dir.releaseLock(lock.relPath)
+		delete(h.held, key)
+	}
+}
+
+// HandleLock processes a lock request from WASM
+func (h *LockHolder) HandleLock(requestJSON []byte) []byte {
+	var req LockRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: "invalid request"})
+	}
+
+	timeout := defaultLockTimeout
+	if req.TimeoutMs > 0 {
+		timeout = min(time.Duration(req.TimeoutMs)*time.Millisecond, maxLockTimeout)
+	}
+
+	if err := h.Lock(req.Path, timeout); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(FileResponse{Success: true})
+}
+
+// HandleUnlock processes an unlock request from WASM
+func (h *LockHolder) HandleUnlock(requestJSON []byte) []byte {
+	var req LockRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: "invalid request"})
+	}
+
+	if err := h.Unlock(req.Path); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(FileResponse{Success: true})
+}
+
+// pathLock is the advisory lock on one path
+type pathLock struct {
+	ch   chan struct{} // Holds a token while the lock is taken
+	refs int           // Holder and waiters; the lock is removed from the directory at zero
+}
+
+// acquireLock waits for the lock on a relative path
+func (d *Directory) acquireLock(ctx context.Context, relPath string, timeout time.Duration) error {
+	d.locksMu.Lock()
+	lock, ok := d.locks[relPath]
+	if !ok {
+		lock = &pathLock{ch: make(chan struct{}, 1)}
+		d.locks[relPath] = lock
+	}
+	lock.refs++
+	d.locksMu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case lock.ch <- struct{}{}:
+		return nil
+	case <-timer.C:
+		d.unrefLock(relPath, lock)
+		return fmt.Errorf("timed out after %v waiting for lock", timeout)
+	case <-ctx.Done():
+		d.unrefLock(relPath, lock)
+		return ctx.Err()
+	}
+}
+
+// releaseLock releases the lock on a relative path
+func (d *Directory) releaseLock(relPath string) {
+	d.locksMu.Lock()
+	lock, ok := d.locks[relPath]
+	d.locksMu.Unlock()
+
+	if ok {
+		select {
+		case <-lock.ch:
+		default:
+		}
+		d.unrefLock(relPath, lock)
+	}
+}
+
+// unrefLock drops a holder or waiter of a lock, removing the lock once nobody uses it
+func (d *Directory) unrefLock(relPath string, lock *pathLock) {
+	d.locksMu.Lock()
+	defer d.locksMu.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 && d.locks[relPath] == lock {
+		delete(d.locks, relPath)
+	}
+}
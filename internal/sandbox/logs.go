@@ -0,0 +1,88 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	extism "github.com/extism/go-sdk"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Loggers naming the source of the log messages of an execution
+const (
+	LoggerSandbox = "sandbox" // Host functions serving the execution
+	LoggerConsole = "console" // console.* output of sandbox code
+)
+
+// LogFunc receives the log messages of an execution at their MCP logging level
+type LogFunc func(level mcp.LoggingLevel, logger, message string)
+
+func init() {
+	// Plugin logs are filtered by the level each MCP client requests, so let the
+	// runtime pass them all on; its process-wide default drops every message
+	extism.SetLogLevel(extism.LogLevelDebug)
+}
+
+// pluginLevels maps plugin log levels to MCP logging levels
+var pluginLevels = map[extism.LogLevel]mcp.LoggingLevel{
+	extism.LogLevelTrace: "debug",
+	extism.LogLevelDebug: "debug",
+	extism.LogLevelInfo:  "info",
+	extism.LogLevelWarn:  "warning",
+	extism.LogLevelError: "error",
+}
+
+// consoleLevels maps console methods to MCP logging levels
+var consoleLevels = map[string]mcp.LoggingLevel{
+	"debug": "debug",
+	"log":   "info",
+	"info":  "info",
+	"warn":  "warning",
+	"error": "error",
+}
+
+// ConsoleRequest represents console output from WASM
+type ConsoleRequest struct {
+	Method  string `json:"method"`  // Console method, e.g. "log" or "error"
+	Message string `json:"message"` // Formatted arguments
+}
+
+// ConsoleResponse represents the result of writing console output
+type ConsoleResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// logPlugin handles log messages of the host functions and the plugin runtime.
+// They go to the server log at debug level and to the execution's LogFunc, if any.
+func (s *Sandbox) logPlugin(level extism.LogLevel, message string) {
+	mcpLevel, ok := pluginLevels[level]
+	if !ok {
+		return
+	}
+
+	slog.DebugContext(s.ctx, "Sandbox log", "level", mcpLevel, "message", message)
+	if s.log != nil {
+		s.log(mcpLevel, LoggerSandbox, message)
+	}
+}
+
+// handleConsole writes console output of sandbox code to the server log at debug level,
+// and passes it to the execution's LogFunc when console forwarding is enabled
+func (s *Sandbox) handleConsole(requestJSON []byte) []byte {
+	var req ConsoleRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(ConsoleResponse{Success: false, Error: "invalid request"})
+	}
+
+	level, ok := consoleLevels[req.Method]
+	if !ok {
+		level = "info"
+	}
+
+	slog.DebugContext(s.ctx, "Sandbox console output", "method", req.Method, "message", req.Message)
+	if s.log != nil && s.forwardConsole {
+		s.log(level, LoggerConsole, req.Message)
+	}
+	return mustMarshal(ConsoleResponse{Success: true})
+}
@@ -0,0 +1,12 @@
+package sandbox
+
+import (
+	"github.com/yousuf/runbyte/internal/metrics"
+)
+
+// Sandbox metrics
+var (
+	sandboxesActive      = metrics.NewGauge("runbyte_sandboxes_active", "Sandboxes created and not yet closed")
+	sandboxCreateSeconds = metrics.NewHistogram("runbyte_sandbox_create_duration_seconds", "Time to instantiate a WASM sandbox", metrics.DurationBuckets)
+	callSlotsInUse       = metrics.NewGauge("runbyte_sandbox_call_slots_in_use", "Tool call slots of running executions held by calls in flight")
+)
@@ -3,7 +3,9 @@ package sandbox
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	extism "github.com/extism/go-sdk"
 	"github.com/yousuf/runbyte/internal/client"
@@ -16,6 +18,12 @@ type Sandbox struct {
 	clientHub  *client.McpClientHub
 	ctx        context.Context
 	filesystem *SandboxFileSystem
+	locks      *LockHolder // Path locks held by this execution
+	calls      *toolCalls  // MCP tool calls submitted by this execution
+	clock      executionClock
+
+	log            LogFunc // Receives log messages of the execution (optional)
+	forwardConsole bool    // Pass console.* output to log
 }
 
 type ExecuteCodeResult struct {
@@ -24,8 +32,11 @@ type ExecuteCodeResult struct {
 	Result string
 }
 
-// NewSandbox creates a new sandbox instance from WASM bytes
-func NewSandbox(ctx context.Context, wasmBytes []byte, clientHub *client.McpClientHub, filesystem *SandboxFileSystem) (*Sandbox, error) {
+// NewSandbox creates a new sandbox instance from WASM bytes.
+// filesystem, kv and fetcher may be nil, in which case the corresponding APIs return errors.
+// A deadline on ctx is the execution deadline: code still running then is stopped.
+func NewSandbox(ctx context.Context, wasmBytes []byte, clientHub *client.McpClientHub, filesystem *SandboxFileSystem, kv *KVStore, fetcher *Fetcher, opts Options) (*Sandbox, error) {
+	start := time.Now()
 	manifest := extism.Manifest{
 		Wasm: []extism.Wasm{
 			extism.WasmData{
@@ -33,70 +44,137 @@ func NewSandbox(ctx context.Context, wasmBytes []byte, clientHub *client.McpClie
 			},
 		},
 	}
+	if deadline, ok := ctx.Deadline(); ok {
+		// A timeout makes the runtime stop guest code when the context is done
+		manifest.Timeout = uint64(max(time.Until(deadline).Milliseconds(), 1))
+	}
 
 	config := extism.PluginConfig{
 		EnableWasi: true,
 	}
 
 	sb := &Sandbox{
-		clientHub:  clientHub,
-		ctx:        ctx,
-		filesystem: filesystem,
+		clientHub: clientHub,
+		ctx:       ctx,
+		calls:     newToolCalls(ctx, clientHub, opts),
+
+		log:            opts.Log,
+		forwardConsole: opts.ForwardConsole,
 	}
 
-	// Combine MCP and filesystem host functions
+	// Combine MCP, fetch, key-value and filesystem host functions
 	hostFunctions := []extism.HostFunction{
 		createCallMcpToolHostFunc(sb),
+		createJSONHostFunc("callMcpToolBatch", "Calling MCP tool batch", sb.handleCallBatch),
+		createJSONHostFunc("mcp_poll", "Waiting for MCP tool calls", sb.handlePoll),
+		createJSONHostFunc("http_fetch", "Performing HTTP request", func(requestJSON []byte) (response []byte) {
+			sb.clock.timeHost(func() {
+				response = fetcher.HandleFetch(ctx, requestJSON)
+			})
+			return response
+		}),
+		createJSONHostFunc("timer_sleep", "Waiting for timer", sb.handleSleep),
+		createJSONHostFunc("console_log", "", sb.handleConsole), // Not logged, as it is logging itself,
 	}
+	hostFunctions = append(hostFunctions, createKVHostFunctions(kv)...)
 	if filesystem != nil {
-		hostFunctions = append(hostFunctions, createWorkspaceHostFunctions(filesystem)...)
+		// Per-execution directories get a subdirectory of their own for this execution
+		execFS, err := filesystem.ForExecution()
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare filesystem: %w", err)
+		}
+		sb.filesystem = execFS
+		sb.locks = execFS.NewLockHolder(ctx)
+		hostFunctions = append(hostFunctions, createWorkspaceHostFunctions(execFS, sb.locks)...)
 	}
 
 	plugin, err := extism.NewPlugin(ctx, manifest, config, hostFunctions)
+	sandboxCreateSeconds.Observe(time.Since(start).Seconds())
 	if err != nil {
+		if sb.filesystem != nil {
+			sb.filesystem.RemoveExecutionDirectories()
+		}
 		return nil, fmt.Errorf("failed to create plugin: %w", err)
 	}
 
+	plugin.SetLogger(sb.logPlugin)
 	sb.plugin = plugin
+	sandboxesActive.Add(1)
 	return sb, nil
 }
 
-// ExecuteCode executes bundled JavaScript code in the sandbox
-func (s *Sandbox) ExecuteCode(bundledCode, sourceMap string) (string, error) {
+// ExecuteCode executes bundled JavaScript code in the sandbox.
+// Tool calls still running are cancelled, path locks still held are released and
+// the execution's per-execution directories (e.g., temp) are removed once it finishes.
+func (s *Sandbox) ExecuteCode(bundledCode, sourceMap string) (result string, err error) {
+	defer s.calls.Close()
+	if s.filesystem != nil {
+		defer s.locks.ReleaseAll()
+		defer func() {
+			if cleanupErr := s.filesystem.RemoveExecutionDirectories(); cleanupErr != nil && err == nil {
+				err = fmt.Errorf("failed to remove per-execution directories: %w", cleanupErr)
+			}
+		}()
+	}
+
+	s.clock.started = time.Now()
+	s.calls.trace.started = s.clock.started
+	defer func() {
+		s.clock.finished = time.Now()
+	}()
+
 	// Call the executeCode function exported by the JavaScript plugin
-	exit, output, err := s.plugin.Call("executeCode", []byte(bundledCode))
+	exit, output, err := s.plugin.CallWithContext(s.ctx, "executeCode", []byte(bundledCode))
 	if err != nil {
+		if errors.Is(s.ctx.Err(), context.DeadlineExceeded) {
+			return "", errors.New("execution timed out: code did not finish before the execution deadline")
+		}
 		return "", fmt.Errorf("plugin execution failed: %w", err)
 	}
 	if exit != 0 {
 		return "", fmt.Errorf("plugin exited with code %d", exit)
 	}
 
-	var result ExecuteCodeResult
-	err = json.Unmarshal(output, &result)
+	var execResult ExecuteCodeResult
+	err = json.Unmarshal(output, &execResult)
 	if err != nil {
 		return "", fmt.Errorf("failed to unmarshal output: %w", err)
 	}
 
-	if result.Error != "" {
-		if result.Stack != "" {
-			mappedStack, err := sourcemap.Map(sourceMap, result.Stack, true)
+	if execResult.Error != "" {
+		if execResult.Stack != "" {
+			mappedStack, err := sourcemap.Map(sourceMap, execResult.Stack, true)
 			if err != nil {
 				return "", fmt.Errorf("failed to map error stack trace: %w", err)
 			}
 
-			return "", fmt.Errorf("failed to execute code\nerror:\n%s\nstack trace:\n%s", result.Error, mappedStack)
+			return "", fmt.Errorf("failed to execute code\nerror:\n%s\nstack trace:\n%s", execResult.Error, mappedStack)
 		}
 
-		return "", fmt.Errorf("failed to execute code:\n%s", result.Error)
+		return "", fmt.Errorf("failed to execute code:\n%s", execResult.Error)
 	}
 
-	return result.Result, nil
+	return execResult.Result, nil
+}
+
+// Stats returns timing and tool call statistics of the last execution
+func (s *Sandbox) Stats() ExecutionStats {
+	stats := s.clock.stats()
+	stats.Calls = s.calls.recorder.Stats()
+	return stats
+}
+
+// Trace returns the MCP tool calls made by the last execution, in call order.
+// It is empty when the trace verbosity is TraceOff.
+func (s *Sandbox) Trace() []TraceEntry {
+	return s.calls.trace.Entries()
 }
 
 // Close closes the sandbox and frees resources
 func (s *Sandbox) Close() {
 	if s.plugin != nil {
 		s.plugin.Close(s.ctx)
+		s.plugin = nil
+		sandboxesActive.Add(-1)
 	}
 }
@@ -0,0 +1,427 @@
+package sandbox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// serversDirName is the virtual directory holding the generated TypeScript libraries of MCP servers
+const serversDirName = "servers"
+
+// Search limits
+const (
+	DefaultSearchLimit = 100     // Results returned when no limit is given
+	MaxSearchLimit     = 1000    // Upper bound for requested limits
+	maxGrepFileSize    = 1 << 20 // Larger files are skipped by grep
+	maxGrepLineLength  = 300     // Matching lines are truncated to this many characters
+	binarySniffLength  = 8000    // Bytes inspected to detect binary files
+)
+
+// errSearchLimit stops a walk once enough results are collected
+var errSearchLimit = errors.New("search limit reached")
+
+// SearchRequest represents a glob or grep request
+type SearchRequest struct {
+	Path       string `json:"path,omitempty"`       // Directory to search (e.g., '/workspace/reports', '/servers/github'); empty searches all directories
+	Pattern    string `json:"pattern,omitempty"`    // Glob for file paths; without '/' it matches file names at any depth (default: '*')
+	Query      string `json:"query,omitempty"`      // Regular expression matched against lines (grep only)
+	IgnoreCase bool   `json:"ignoreCase,omitempty"` // Case-insensitive query (grep only)
+	MaxDepth   int    `json:"maxDepth,omitempty"`   // Maximum directory depth below Path (0 is unlimited; 1 is Path only)
+	Limit      int    `json:"limit,omitempty"`      // Maximum number of results (default: DefaultSearchLimit)
+}
+
+// SearchMatch is a file found by glob, or a matching line found by grep
+type SearchMatch struct {
+	Path    string `json:"path"` // Virtual path (e.g., '/workspace/reports/summary.md')
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtimeMs"`        // Unix milliseconds
+	Line    int    `json:"line,omitempty"` // 1-based line number (grep only)
+	Text    string `json:"text,omitempty"` // Matching line (grep only)
+}
+
+// SearchResponse represents a glob or grep response
+type SearchResponse struct {
+	Success   bool          `json:"success"`
+	Matches   []SearchMatch `json:"matches,omitempty"`
+	Truncated bool          `json:"truncated,omitempty"` // More results exist beyond the limit
+	Error     string        `json:"error,omitempty"`
+}
+
+// searchRoot is a directory tree covered by a search
+type searchRoot struct {
+	name  string     // Virtual directory name (e.g., "workspace", "servers")
+	root  string     // Directory root on disk
+	start string     // Absolute path the walk starts at
+	dir   *Directory // Mounted directory, nil for the servers tree
+}
+
+// SetServersDir makes the generated server libraries searchable as '/servers'.
+// The servers tree can be searched but not read or written through the filesystem.
+func (sfs *SandboxFileSystem) SetServersDir(dir string) {
+	sfs.mu.Lock()
+	defer sfs.mu.Unlock()
+	sfs.serversDir = dir
+}
+
+// Glob finds files whose path matches a glob pattern, ordered by path within each directory
+func (sfs *SandboxFileSystem) Glob(req SearchRequest) ([]SearchMatch, bool, error) {
+	matcher, err := newGlobMatcher(req.Pattern)
+	if err != nil {
+		return nil, false, err
+	}
+
+	limit := searchLimit(req.Limit)
+	matches := []SearchMatch{}
+	truncated, err := sfs.walkSearch(req, func(sr searchRoot, rel string, info fs.FileInfo) error {
+		if !matcher(rel) {
+			return nil
+		}
+		if len(matches) == limit {
+			return errSearchLimit
+		}
+		matches = append(matches, newSearchMatch(sr, rel, info))
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return matches, truncated, nil
+}
+
+// Grep finds lines matching a regular expression in files matching an optional glob.
+// Binary files and files larger than 1MB are skipped.
+func (sfs *SandboxFileSystem) Grep(req SearchRequest) ([]SearchMatch, bool, error) {
+	if req.Query == "" {
+		return nil, false, errors.New("query is required")
+	}
+	expr := req.Query
+	if req.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid query: %w", err)
+	}
+
+	matcher, err := newGlobMatcher(req.Pattern)
+	if err != nil {
+		return nil, false, err
+	}
+
+	limit := searchLimit(req.Limit)
+	matches := []SearchMatch{}
+	truncated, err := sfs.walkSearch(req, func(sr searchRoot, rel string, info fs.FileInfo) error {
+		if !matcher(rel) || info.Size() > maxGrepFileSize {
+			return nil
+		}
+
+		content, err := os.ReadFile(filepath.Join(sr.start, filepath.FromSlash(rel)))
+		if err != nil || isBinary(content) {
+			return nil // Files may change during the walk; skip unreadable ones
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(make([]byte, 0, 64*1024), maxGrepFileSize+1)
+		for lineNum := 1; scanner.Scan(); lineNum++ {
+			line := scanner.Text()
+			if !re.MatchString(line) {
+				continue
+			}
+			if len(matches) == limit {
+				return errSearchLimit
+			}
+			match := newSearchMatch(sr, rel, info)
+			match.Line = lineNum
+			match.Text = truncateLine(line)
+			matches = append(matches, match)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return matches, truncated, nil
+}
+
+// HandleGlob processes a glob request from WASM
+func (sfs *SandboxFileSystem) HandleGlob(requestJSON []byte) []byte {
+	var req SearchRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(SearchResponse{Success: false, Error: "invalid request"})
+	}
+
+	matches, truncated, err := sfs.Glob(req)
+	if err != nil {
+		return mustMarshal(SearchResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(SearchResponse{Success: true, Matches: matches, Truncated: truncated})
+}
+
+// HandleGrep processes a grep request from WASM
+func (sfs *SandboxFileSystem) HandleGrep(requestJSON []byte) []byte {
+	var req SearchRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(SearchResponse{Success: false, Error: "invalid request"})
+	}
+
+	matches, truncated, err := sfs.Grep(req)
+	if err != nil {
+		return mustMarshal(SearchResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(SearchResponse{Success: true, Matches: matches, Truncated: truncated})
+}
+
+// walkSearch calls fn with every regular file under the requested path, skipping internal
+// files and symlinks. Paths passed to fn are slash-separated and relative to the search start.
+// It reports whether the walk was stopped by the result limit.
+func (sfs *SandboxFileSystem) walkSearch(req SearchRequest, fn func(sr searchRoot, rel string, info fs.FileInfo) error) (bool, error) {
+	roots, err := sfs.searchRoots(req.Path)
+	if err != nil {
+		return false, err
+	}
+	if req.MaxDepth < 0 {
+		return false, errors.New("maxDepth must not be negative")
+	}
+
+	for _, sr := range roots {
+		if sr.dir != nil {
+			sr.dir.mu.RLock()
+		}
+		err := walkSearchRoot(sr, req.MaxDepth, fn)
+		if sr.dir != nil {
+			sr.dir.mu.RUnlock()
+		}
+
+		if errors.Is(err, errSearchLimit) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// walkSearchRoot walks a single search root
+func walkSearchRoot(sr searchRoot, maxDepth int, fn func(sr searchRoot, rel string, info fs.FileInfo) error) error {
+	return filepath.WalkDir(sr.start, func(fullPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if fullPath == sr.start && errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("directory not found: %s", sr.virtualPath(""))
+			}
+			if entry != nil && entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fullPath == sr.start {
+			if !entry.IsDir() {
+				return fmt.Errorf("not a directory: %s", sr.virtualPath(""))
+			}
+			return nil
+		}
+
+		if isReservedName(entry.Name()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(sr.start, fullPath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		depth := strings.Count(rel, "/") + 1
+
+		if entry.IsDir() {
+			if maxDepth > 0 && depth >= maxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		return fn(sr, rel, info)
+	})
+}
+
+// searchRoots resolves a search path to the directory trees it covers.
+// An empty path or '/' covers all mounted directories and the servers tree.
+func (sfs *SandboxFileSystem) searchRoots(userPath string) ([]searchRoot, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(userPath, "./"), "/")
+	trimmed = strings.TrimSuffix(trimmed, "/")
+
+	sfs.mu.RLock()
+	defer sfs.mu.RUnlock()
+	serversDir := sfs.serversDir
+
+	if trimmed == "" {
+		roots := make([]searchRoot, 0, len(sfs.names)+1)
+		for _, name := range sfs.names {
+			dir := sfs.directories[name]
+			roots = append(roots, searchRoot{name: name, root: dir.config.Root, start: dir.config.Root, dir: dir})
+		}
+		if serversDir != "" {
+			roots = append(roots, searchRoot{name: serversDirName, root: serversDir, start: serversDir})
+		}
+		return roots, nil
+	}
+
+	dirName, relPath, _ := strings.Cut(trimmed, "/")
+	dir, mounted := sfs.directories[dirName]
+	if !mounted {
+		if dirName != serversDirName || serversDir == "" {
+			return nil, fmt.Errorf("unknown directory '%s', available: %v", dirName, sfs.names)
+		}
+		if strings.Contains(relPath, "..") {
+			return nil, errors.New("path traversal not allowed")
+		}
+		start := filepath.Join(serversDir, filepath.FromSlash(relPath))
+		return []searchRoot{{name: serversDirName, root: serversDir, start: start}}, nil
+	}
+
+	start, err := dir.validatePath(relPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return []searchRoot{{name: dir.config.Name, root: dir.config.Root, start: start, dir: dir}}, nil
+}
+
+// virtualPath converts a path relative to the search start into a virtual path
+func (sr searchRoot) virtualPath(rel string) string {
+	full := filepath.Join(sr.start, filepath.FromSlash(rel))
+	fromRoot, err := filepath.Rel(sr.root, full)
+	if err != nil || fromRoot == "." {
+		return "/" + sr.name
+	}
+	return "/" + sr.name + "/" + filepath.ToSlash(fromRoot)
+}
+
+// newSearchMatch describes a file found by a search
+func newSearchMatch(sr searchRoot, rel string, info fs.FileInfo) SearchMatch {
+	return SearchMatch{
+		Path:    sr.virtualPath(rel),
+		Size:    info.Size(),
+		ModTime: info.ModTime().UnixMilli(),
+	}
+}
+
+// newGlobMatcher compiles a glob pattern into a matcher for slash-separated relative paths.
+// '**' matches any number of directories; patterns without '/' match file names at any depth.
+func newGlobMatcher(pattern string) (func(rel string) bool, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+	pattern = strings.TrimPrefix(pattern, "./")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	segments := strings.Split(pattern, "/")
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	if len(segments) == 1 {
+		return func(rel string) bool {
+			ok, _ := path.Match(pattern, path.Base(rel))
+			return ok
+		}, nil
+	}
+
+	return func(rel string) bool {
+		return matchSegments(segments, strings.Split(rel, "/"))
+	}, nil
+}
+
+// matchSegments matches path segments against pattern segments, where '**' matches zero or more segments
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// searchLimit applies the default and maximum result limits
+func searchLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		return MaxSearchLimit
+	}
+	return limit
+}
+
+// isBinary reports whether content looks like binary data
+func isBinary(content []byte) bool {
+	sniff := content
+	if len(sniff) > binarySniffLength {
+		sniff = sniff[:binarySniffLength]
+	}
+	return bytes.IndexByte(sniff, 0) >= 0 || !utf8.Valid(trimPartialRune(sniff))
+}
+
+// trimPartialRune drops a UTF-8 sequence cut off at the end of a prefix
+func trimPartialRune(b []byte) []byte {
+	for i := 0; i < utf8.UTFMax && i < len(b); i++ {
+		if utf8.RuneStart(b[len(b)-1-i]) {
+			if !utf8.FullRune(b[len(b)-1-i:]) {
+				return b[:len(b)-1-i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// truncateLine shortens long matching lines, keeping valid UTF-8
+func truncateLine(line string) string {
+	line = strings.TrimRight(line, "\r")
+	if len(line) <= maxGrepLineLength {
+		return line
+	}
+	cut := maxGrepLineLength
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + "…"
+}
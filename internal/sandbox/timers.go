@@ -0,0 +1,101 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/yousuf/runbyte/internal/client"
+)
+
+// SleepRequest represents a timer wait from WASM
+type SleepRequest struct {
+	Ms int64 `json:"ms"` // Time to block until the next timer is due
+}
+
+// SleepResponse represents the result of a timer wait
+type SleepResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ExecutionStats describes where the time of a code execution went
+type ExecutionStats struct {
+	DurationMs int64 `json:"durationMs"` // Wall-clock time of the execution
+	CPUMs      int64 `json:"cpuMs"`      // Time running sandbox code (duration minus sleep and host waits)
+	SleepMs    int64 `json:"sleepMs"`    // Time blocked waiting for timers (setTimeout, setInterval, sleep)
+	HostMs     int64 `json:"hostMs"`     // Time blocked on MCP tool calls and fetch requests
+
+	Calls client.CallStats `json:"calls"` // MCP tool calls and the effect of server call policies
+}
+
+// executionClock accumulates the time an execution spends outside sandbox code
+type executionClock struct {
+	started  time.Time
+	finished time.Time
+	sleep    atomic.Int64 // Nanoseconds
+	host     atomic.Int64 // Nanoseconds
+}
+
+// timeHost runs fn and counts its duration as host wait time
+func (c *executionClock) timeHost(fn func()) {
+	start := time.Now()
+	defer func() {
+		c.host.Add(int64(time.Since(start)))
+	}()
+	fn()
+}
+
+// stats summarizes the clock
+func (c *executionClock) stats() ExecutionStats {
+	end := c.finished
+	if end.IsZero() {
+		end = time.Now()
+	}
+	duration := end.Sub(c.started)
+	sleep := time.Duration(c.sleep.Load())
+	host := time.Duration(c.host.Load())
+
+	return ExecutionStats{
+		DurationMs: duration.Milliseconds(),
+		CPUMs:      max(duration-sleep-host, 0).Milliseconds(),
+		SleepMs:    sleep.Milliseconds(),
+		HostMs:     host.Milliseconds(),
+	}
+}
+
+// handleSleep blocks until the next timer is due. Waits that would end after the
+// execution deadline fail immediately instead of running into the timeout.
+func (s *Sandbox) handleSleep(requestJSON []byte) []byte {
+	var req SleepRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(SleepResponse{Success: false, Error: "invalid request"})
+	}
+	if req.Ms <= 0 {
+		return mustMarshal(SleepResponse{Success: true})
+	}
+
+	wait := time.Duration(req.Ms) * time.Millisecond
+	if deadline, ok := s.ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); wait > remaining {
+			return mustMarshal(SleepResponse{Success: false, Error: fmt.Sprintf(
+				"timer due in %dms would fire after the execution deadline (%dms left)", req.Ms, max(remaining.Milliseconds(), 0))})
+		}
+	}
+
+	start := time.Now()
+	defer func() {
+		s.clock.sleep.Add(int64(time.Since(start)))
+	}()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return mustMarshal(SleepResponse{Success: true})
+	case <-s.ctx.Done():
+		return mustMarshal(SleepResponse{Success: false, Error: "execution cancelled while waiting for a timer"})
+	}
+}
@@ -0,0 +1,207 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Trace verbosity levels
+const (
+	TraceOff     = "off"     // No trace
+	TraceSummary = "summary" // Calls with shortened arguments
+	TraceFull    = "full"    // Calls with complete arguments
+)
+
+// Trace entry statuses
+const (
+	TraceStatusOK        = "ok"
+	TraceStatusError     = "error"
+	TraceStatusCancelled = "cancelled" // Stopped by the execution deadline; the server may still have acted on it
+)
+
+// Limits of argument summaries
+const (
+	traceMaxStringLength = 60 // Characters kept of long strings
+	traceMaxItems        = 5  // Items kept of long arrays
+	traceMaxDepth        = 2  // Nesting levels kept of objects and arrays
+)
+
+// traceRedacted replaces the values of sensitive arguments
+const traceRedacted = "[REDACTED]"
+
+// TraceOptions configures the tool call trace of an execution
+type TraceOptions struct {
+	Verbosity string   // TraceOff, TraceSummary or TraceFull (defaults to TraceSummary)
+	Redact    []string // Argument names whose values are redacted, matched case-insensitively as substrings
+}
+
+// TraceEntry records an MCP tool call made by an execution
+type TraceEntry struct {
+	Seq         int            `json:"seq"` // Order in which the call was made, from 1
+	Server      string         `json:"server"`
+	Tool        string         `json:"tool"`
+	Args        map[string]any `json:"args,omitempty"` // Redacted arguments, shortened unless the verbosity is full
+	StartMs     int64          `json:"startMs"`        // Offset from the start of the execution
+	DurationMs  int64          `json:"durationMs"`     // Includes waiting for a free call slot
+	Status      string         `json:"status"`         // ok, error or cancelled
+	Error       string         `json:"error,omitempty"`
+	ResultBytes int            `json:"resultBytes"` // Size of the result passed to the code
+
+	start time.Time
+}
+
+// callTrace collects the trace entries of an execution
+type callTrace struct {
+	verbosity string
+	redact    []string
+	started   time.Time
+
+	mu      sync.Mutex
+	entries []*TraceEntry
+}
+
+// newCallTrace creates the trace of an execution; it records nothing when verbosity is TraceOff
+func newCallTrace(opts TraceOptions) *callTrace {
+	verbosity := opts.Verbosity
+	if verbosity == "" {
+		verbosity = TraceSummary
+	}
+
+	redact := make([]string, 0, len(opts.Redact))
+	for _, name := range opts.Redact {
+		redact = append(redact, normalizeArgName(name))
+	}
+
+	return &callTrace{verbosity: verbosity, redact: redact, started: time.Now()}
+}
+
+// begin records the start of a call. It returns nil when tracing is off.
+func (t *callTrace) begin(call McpToolCall) *TraceEntry {
+	if t.verbosity == TraceOff {
+		return nil
+	}
+
+	entry := &TraceEntry{
+		Server: call.ServerName,
+		Tool:   call.ToolName,
+		Args:   t.summarizeArgs(call.Args),
+		start:  time.Now(),
+	}
+	entry.StartMs = entry.start.Sub(t.started).Milliseconds()
+
+	t.mu.Lock()
+	t.entries = append(t.entries, entry)
+	entry.Seq = len(t.entries)
+	t.mu.Unlock()
+
+	return entry
+}
+
+// finish records the outcome of a call started with begin
+func (t *callTrace) finish(ctx context.Context, entry *TraceEntry, response McpToolResponse) {
+	if entry == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry.DurationMs = time.Since(entry.start).Milliseconds()
+	entry.ResultBytes = len(response.Result)
+	switch {
+	case response.Error == "":
+		entry.Status = TraceStatusOK
+	case ctx.Err() != nil:
+		entry.Status, entry.Error = TraceStatusCancelled, response.Error
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			entry.Error = "execution deadline reached"
+		}
+	default:
+		entry.Status, entry.Error = TraceStatusError, response.Error
+	}
+}
+
+// Entries returns a copy of the trace in call order
+func (t *callTrace) Entries() []TraceEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make([]TraceEntry, len(t.entries))
+	for i, entry := range t.entries {
+		entries[i] = *entry
+	}
+	return entries
+}
+
+// summarizeArgs redacts sensitive arguments and, unless the verbosity is full,
+// shortens long strings and collapses deeply nested values
+func (t *callTrace) summarizeArgs(args map[string]interface{}) map[string]any {
+	if len(args) == 0 {
+		return nil
+	}
+	return t.summarizeObject(args, 0)
+}
+
+func (t *callTrace) summarizeObject(obj map[string]interface{}, depth int) map[string]any {
+	summary := make(map[string]any, len(obj))
+	for key, val := range obj {
+		if t.sensitive(key) {
+			summary[key] = traceRedacted
+			continue
+		}
+		summary[key] = t.summarizeValue(val, depth+1)
+	}
+	return summary
+}
+
+func (t *callTrace) summarizeValue(val any, depth int) any {
+	full := t.verbosity == TraceFull
+	switch v := val.(type) {
+	case string:
+		if !full && utf8.RuneCountInString(v) > traceMaxStringLength {
+			return string([]rune(v)[:traceMaxStringLength]) + "…"
+		}
+		return v
+	case map[string]interface{}:
+		if !full && depth > traceMaxDepth {
+			return fmt.Sprintf("{%d keys}", len(v))
+		}
+		return t.summarizeObject(v, depth)
+	case []interface{}:
+		if !full && depth > traceMaxDepth {
+			return fmt.Sprintf("[%d items]", len(v))
+		}
+		items := make([]any, 0, min(len(v), traceMaxItems+1))
+		for i, item := range v {
+			if !full && i == traceMaxItems {
+				items = append(items, fmt.Sprintf("… %d more", len(v)-i))
+				break
+			}
+			items = append(items, t.summarizeValue(item, depth+1))
+		}
+		return items
+	default:
+		return v
+	}
+}
+
+// sensitive reports whether an argument name matches a redacted name
+func (t *callTrace) sensitive(name string) bool {
+	name = normalizeArgName(name)
+	for _, redact := range t.redact {
+		if strings.Contains(name, redact) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeArgName lowercases a name and drops separators, so "api_key" matches "apiKey"
+func normalizeArgName(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
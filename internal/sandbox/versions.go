@@ -0,0 +1,295 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// versionsDirName holds previous versions of files, keyed by relative path.
+// Each version is a copy of the file named by the time it was replaced.
+const versionsDirName = reservedPrefix + "versions"
+
+// versionIDLength is the length of a version ID (zero-padded Unix nanoseconds, so IDs sort by age)
+const versionIDLength = 20
+
+// VersionInfo describes a previous version of a file
+type VersionInfo struct {
+	ID      string `json:"id"`
+	Size    int64  `json:"size"`
+	SavedAt int64  `json:"savedAtMs"` // Unix milliseconds when the version was replaced
+}
+
+// ListVersions lists the previous versions of a file, newest first
+func (sfs *SandboxFileSystem) ListVersions(userPath string) ([]VersionInfo, error) {
+	dir, relPath, err := sfs.resolve(userPath, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return dir.ListVersions(relPath)
+}
+
+// RestoreVersion restores a previous version of a file in a writable directory.
+// The content being replaced is kept as a new version, so a restore can be undone.
+func (sfs *SandboxFileSystem) RestoreVersion(userPath, versionID string) error {
+	dir, relPath, err := sfs.resolve(userPath, true)
+	if err != nil {
+		return err
+	}
+
+	if err := dir.RestoreVersion(relPath, versionID); err != nil {
+		return err
+	}
+	sfs.changed(dir, relPath)
+	return nil
+}
+
+// HandleListVersions processes a list versions request from WASM
+func (sfs *SandboxFileSystem) HandleListVersions(requestJSON []byte) []byte {
+	var req FileRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: "invalid request"})
+	}
+
+	versions, err := sfs.ListVersions(req.Path)
+	if err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(FileResponse{Success: true, Versions: versions})
+}
+
+// HandleRestoreVersion processes a restore request from WASM
+func (sfs *SandboxFileSystem) HandleRestoreVersion(requestJSON []byte) []byte {
+	var req FileRequest
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: "invalid request"})
+	}
+
+	if err := sfs.RestoreVersion(req.Path, req.Version); err != nil {
+		return mustMarshal(FileResponse{Success: false, Error: err.Error()})
+	}
+
+	return mustMarshal(FileResponse{Success: true})
+}
+
+// ListVersions lists the previous versions of a file, newest first.
+// Versions of deleted files are listed too, so deletes can be undone.
+func (d *Directory) ListVersions(relPath string) ([]VersionInfo, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	fullPath, err := d.validatePath(relPath)
+	if err != nil {
+		return nil, err
+	}
+	if fullPath == d.config.Root {
+		return nil, errors.New("versions are kept for files, not the directory root")
+	}
+
+	versions, err := d.fileVersions(relPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Newest first
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+	return versions, nil
+}
+
+// RestoreVersion replaces a file with one of its previous versions
+func (d *Directory) RestoreVersion(relPath, versionID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	fullPath, err := d.validatePath(relPath)
+	if err != nil {
+		return err
+	}
+	if fullPath == d.config.Root {
+		return errors.New("versions are kept for files, not the directory root")
+	}
+	if !isVersionID(versionID) {
+		return fmt.Errorf("invalid version id: %q", versionID)
+	}
+
+	content, err := os.ReadFile(filepath.Join(d.versionDir(relPath), versionID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("version %s not found for %s", versionID, relPath)
+		}
+		return fmt.Errorf("failed to read version: %w", err)
+	}
+
+	return d.writeBytesLocked(relPath, fullPath, content)
+}
+
+// saveVersion keeps a copy of a file about to be replaced or deleted, then prunes its
+// oldest versions beyond MaxVersions. pendingDelta is the change in live data size caused
+// by the operation; a version is skipped when live data alone would leave no room for it.
+// Must be called with d.mu held.
+func (d *Directory) saveVersion(relPath, fullPath string, size, pendingDelta int64) error {
+	if d.config.MaxVersions <= 0 {
+		return nil
+	}
+	if d.stats.TotalBytes+pendingDelta+size > d.config.MaxTotalSize {
+		return nil
+	}
+
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to save previous version: %w", err)
+	}
+
+	versionDir := d.versionDir(relPath)
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		return fmt.Errorf("failed to save previous version: %w", err)
+	}
+
+	// IDs are timestamps; step forward on the rare collision
+	now := time.Now().UnixNano()
+	var versionPath string
+	for {
+		versionPath = filepath.Join(versionDir, formatVersionID(now))
+		if _, err := os.Stat(versionPath); os.IsNotExist(err) {
+			break
+		}
+		now++
+	}
+
+	if err := writeFileAtomic(versionPath, content); err != nil {
+		return fmt.Errorf("failed to save previous version: %w", err)
+	}
+	d.stats.VersionBytes += int64(len(content))
+
+	// Keep only the newest MaxVersions versions of this file
+	versions, err := d.fileVersions(relPath)
+	if err != nil {
+		return nil // Pruning is best effort; trimHistory still enforces the size limit
+	}
+	for len(versions) > d.config.MaxVersions {
+		d.removeVersion(filepath.Join(versionDir, versions[0].ID), versions[0].Size)
+		versions = versions[1:]
+	}
+
+	return nil
+}
+
+// trimHistory removes the oldest versions across the directory until live data and
+// history fit within MaxTotalSize, so history never blocks writes. Must be called with d.mu held.
+func (d *Directory) trimHistory() {
+	if d.stats.VersionBytes == 0 || d.stats.TotalBytes+d.stats.VersionBytes <= d.config.MaxTotalSize {
+		return
+	}
+
+	type version struct {
+		path string
+		id   string
+		size int64
+	}
+	var versions []version
+	filepath.Walk(filepath.Join(d.config.Root, versionsDirName), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() && isVersionID(info.Name()) {
+			versions = append(versions, version{path: path, id: info.Name(), size: info.Size()})
+		}
+		return nil
+	})
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].id < versions[j].id
+	})
+
+	for _, v := range versions {
+		if d.stats.TotalBytes+d.stats.VersionBytes <= d.config.MaxTotalSize {
+			break
+		}
+		d.removeVersion(v.path, v.size)
+	}
+}
+
+// removeVersion deletes a version file and updates stats
+func (d *Directory) removeVersion(path string, size int64) {
+	if err := os.Remove(path); err == nil {
+		d.stats.VersionBytes -= size
+	}
+}
+
+// fileVersions returns the versions of a file, oldest first
+func (d *Directory) fileVersions(relPath string) ([]VersionInfo, error) {
+	entries, err := os.ReadDir(d.versionDir(relPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []VersionInfo{}, nil
+		}
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+
+	// Entries are sorted by name, and names sort by age
+	versions := make([]VersionInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !isVersionID(entry.Name()) {
+			continue // Versions of files inside a directory with the same name
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		nanos, _ := strconv.ParseInt(entry.Name(), 10, 64)
+		versions = append(versions, VersionInfo{
+			ID:      entry.Name(),
+			Size:    info.Size(),
+			SavedAt: time.Unix(0, nanos).UnixMilli(),
+		})
+	}
+
+	return versions, nil
+}
+
+// versionDir returns the directory holding the versions of a file
+func (d *Directory) versionDir(relPath string) string {
+	return filepath.Join(d.config.Root, versionsDirName, filepath.Clean(relPath))
+}
+
+// versionBytesOnDisk returns the total size of all versions in the directory
+func (d *Directory) versionBytesOnDisk() int64 {
+	var total int64
+	filepath.Walk(filepath.Join(d.config.Root, versionsDirName), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() && isVersionID(info.Name()) {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// formatVersionID formats a timestamp as a version ID
+func formatVersionID(unixNano int64) string {
+	return fmt.Sprintf("%0*d", versionIDLength, unixNano)
+}
+
+// isVersionID reports whether a name is a valid version ID
+func isVersionID(name string) bool {
+	if len(name) != versionIDLength {
+		return false
+	}
+	for _, r := range name {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
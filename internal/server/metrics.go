@@ -0,0 +1,22 @@
+package server
+
+import (
+	"github.com/yousuf/runbyte/internal/metrics"
+)
+
+// execute_code outcomes
+const (
+	outcomeOK           = "ok"
+	outcomeTypeError    = "typecheck_error"
+	outcomeBundleError  = "bundle_error"
+	outcomeSandboxError = "sandbox_error" // The sandbox could not be created
+	outcomeRuntimeError = "runtime_error" // The code threw or returned an invalid result
+	outcomeTimeout      = "timeout"       // The execution deadline was reached
+)
+
+// execute_code metrics
+var (
+	executions       = metrics.NewCounter("runbyte_execute_code_total", "execute_code calls by outcome", "outcome")
+	executionSeconds = metrics.NewHistogram("runbyte_execute_code_duration_seconds", "execute_code latency by outcome, from type checking to the end of execution", metrics.DurationBuckets, "outcome")
+	bundleSeconds    = metrics.NewHistogram("runbyte_bundle_duration_seconds", "Time to bundle code with rspack", metrics.DurationBuckets)
+)
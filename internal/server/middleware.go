@@ -2,12 +2,17 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"log"
+	"log/slog"
+	"reflect"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yousuf/runbyte/internal/logging"
 	"github.com/yousuf/runbyte/internal/session"
+	"github.com/yousuf/runbyte/internal/telemetry"
 )
 
 // sessionContextKey is the context key for storing session context
@@ -26,8 +31,9 @@ func getSessionFromContext(ctx context.Context) (*session.SessionContext, error)
 }
 
 // createSessionInjectionMiddleware creates middleware that automatically manages session lifecycle.
-// It stores SessionContext as a value in the request context, keeping request and session lifecycles separate.
-func createSessionInjectionMiddleware(sessionMgr *session.Manager) mcp.Middleware {
+// It stores SessionContext as a value in the request context, keeping request and session lifecycles separate,
+// and records server as the MCP server handling the session.
+func createSessionInjectionMiddleware(server *mcp.Server, sessionMgr *session.Manager) mcp.Middleware {
 	return func(next mcp.MethodHandler) mcp.MethodHandler {
 		return func(
 			ctx context.Context,
@@ -37,7 +43,8 @@ func createSessionInjectionMiddleware(sessionMgr *session.Manager) mcp.Middlewar
 			sessionID := req.GetSession().ID()
 
 			// Get or create session context
-			sessionCtx, err := sessionMgr.GetOrCreateSession(ctx, sessionID)
+			principal := sessionMgr.ResolvePrincipal(req.GetExtra())
+			sessionCtx, err := sessionMgr.GetOrCreateSession(ctx, sessionID, principal)
 			if err != nil {
 				return nil, fmt.Errorf("failed to get/create session: %w", err)
 			}
@@ -49,6 +56,13 @@ func createSessionInjectionMiddleware(sessionMgr *session.Manager) mcp.Middlewar
 			// Update last accessed timestamp
 			sessionCtx.UpdateLastAccessed()
 
+			// Remember the server and the client's MCP session, which receive resource
+			// updates and forwarded log messages
+			sessionCtx.SetServer(server)
+			if clientSession, ok := req.GetSession().(*mcp.ServerSession); ok {
+				sessionCtx.SetClientSession(clientSession)
+			}
+
 			// Store SessionContext as value in request context
 			// This keeps session lifecycle independent from request lifecycle
 			ctx = context.WithValue(ctx, sessionContextKey, sessionCtx)
@@ -59,7 +73,87 @@ func createSessionInjectionMiddleware(sessionMgr *session.Manager) mcp.Middlewar
 	}
 }
 
-// createLoggingMiddleware creates middleware that logs all MCP method calls
+// createLoggingLevelMiddleware creates middleware that passes the logging level requested
+// with logging/setLevel on to the downstream MCP servers of the session, so they only send
+// log messages the client wants. Must run after session injection.
+func createLoggingLevelMiddleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			result, err := next(ctx, method, req)
+			if err != nil || method != "logging/setLevel" {
+				return result, err
+			}
+
+			params, ok := req.GetParams().(*mcp.SetLoggingLevelParams)
+			if !ok || params == nil {
+				return result, nil
+			}
+			sessionCtx, sessionErr := getSessionFromContext(ctx)
+			if sessionErr != nil {
+				return result, nil
+			}
+
+			if err := sessionCtx.ClientHub.SetLoggingLevel(ctx, params.Level); err != nil {
+				slog.WarnContext(ctx, "Failed to set logging level of MCP servers", "level", params.Level, "error", err)
+			}
+			return result, nil
+		}
+	}
+}
+
+// createTracingMiddleware creates middleware that records a server span for each MCP request.
+// Spans continue the caller's trace when the request carries a W3C traceparent.
+func createTracingMiddleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(
+			ctx context.Context,
+			method string,
+			req mcp.Request,
+		) (mcp.Result, error) {
+			// Continue the caller's trace from _meta, or from the HTTP headers
+			meta := requestMeta(req)
+			if traceparent, ok := meta[telemetry.TraceparentKey].(string); ok {
+				ctx = telemetry.WithTraceparent(ctx, traceparent)
+			} else if extra := req.GetExtra(); extra != nil {
+				ctx = telemetry.Extract(ctx, extra.Header)
+			}
+
+			name := method
+			attrs := []telemetry.Attr{
+				{Key: "mcp.method.name", Value: method},
+				{Key: "mcp.session.id", Value: req.GetSession().ID()},
+			}
+			if params, ok := req.GetParams().(*mcp.CallToolParamsRaw); ok && params != nil {
+				name += " " + params.Name
+				attrs = append(attrs, telemetry.Attr{Key: "gen_ai.tool.name", Value: params.Name})
+			}
+
+			ctx, span := telemetry.Start(ctx, name, telemetry.KindServer, attrs...)
+			defer span.End()
+
+			result, err := next(ctx, method, req)
+			if err != nil {
+				span.RecordError(err)
+			} else if res, ok := result.(*mcp.CallToolResult); ok && res.IsError {
+				span.Fail("tool returned an error")
+			}
+			return result, err
+		}
+	}
+}
+
+// requestMeta returns the _meta of a request, or nil if it has no parameters
+func requestMeta(req mcp.Request) map[string]any {
+	params := req.GetParams()
+	if params == nil || reflect.ValueOf(params).IsNil() {
+		return nil
+	}
+	return params.GetMeta()
+}
+
+// createLoggingMiddleware creates middleware that logs all MCP method calls.
+// The request context carries the session and a new request ID, so records logged
+// with it while handling the request can be correlated.
 func createLoggingMiddleware() mcp.Middleware {
 	return func(next mcp.MethodHandler) mcp.MethodHandler {
 		return func(
@@ -68,10 +162,13 @@ func createLoggingMiddleware() mcp.Middleware {
 			req mcp.Request,
 		) (mcp.Result, error) {
 			start := time.Now()
-			sessionID := req.GetSession().ID()
+			ctx = logging.With(ctx,
+				slog.String(logging.SessionKey, req.GetSession().ID()),
+				slog.String(logging.RequestKey, newRequestID()),
+			)
 
 			// Log request details
-			log.Printf("[REQUEST] Session: %s | Method: %s", sessionID, method)
+			slog.DebugContext(ctx, "Request received", "method", method)
 
 			// Call the actual handler
 			result, err := next(ctx, method, req)
@@ -80,14 +177,19 @@ func createLoggingMiddleware() mcp.Middleware {
 			duration := time.Since(start)
 
 			if err != nil {
-				log.Printf("[RESPONSE] Session: %s | Method: %s | Status: ERROR | Duration: %v | Error: %v",
-					sessionID, method, duration, err)
+				slog.WarnContext(ctx, "Request failed", "method", method, "duration", duration, "error", err)
 			} else {
-				log.Printf("[RESPONSE] Session: %s | Method: %s | Status: OK | Duration: %v",
-					sessionID, method, duration)
+				slog.InfoContext(ctx, "Request completed", "method", method, "duration", duration)
 			}
 
 			return result, err
 		}
 	}
 }
+
+// newRequestID returns a random ID identifying a request in the log
+func newRequestID() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yousuf/runbyte/internal/logging"
+	"github.com/yousuf/runbyte/internal/sandbox"
+	"github.com/yousuf/runbyte/internal/session"
+)
+
+// resourceScheme is the URI scheme of sandbox filesystem resources, e.g. runbyte://workspace/report.md
+const resourceScheme = "runbyte"
+
+// resourceOriginKey is the _meta key identifying the directory a resource update came from.
+// Sessions only receive updates for directories they mount, since URIs are only unique per session.
+const resourceOriginKey = "runbyte/directory"
+
+// textMimeTypes are non-text/* mime types whose content is returned as text
+var textMimeTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/javascript": true,
+	"application/typescript": true,
+	"application/x-yaml":     true,
+	"application/yaml":       true,
+	"application/toml":       true,
+	"image/svg+xml":          true,
+}
+
+// extraMimeTypes covers extensions missing from many system mime tables
+var extraMimeTypes = map[string]string{
+	".md":       "text/markdown",
+	".markdown": "text/markdown",
+	".ts":       "application/typescript",
+	".yaml":     "application/yaml",
+	".yml":      "application/yaml",
+	".toml":     "application/toml",
+	".csv":      "text/csv",
+	".log":      "text/plain",
+	".txt":      "text/plain",
+	".json":     "application/json",
+	".jsonl":    "application/jsonl",
+	".ndjson":   "application/x-ndjson",
+}
+
+// addFileResources publishes the session's sandbox filesystem as MCP resources.
+// Subscribers are notified of changes by NotifyResourceUpdates.
+func addFileResources(server *mcp.Server) {
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "sandbox-file",
+		Title:       "Sandbox filesystem file",
+		Description: "Files in the directories mounted into the sandbox (e.g., runbyte://workspace/report.md). Use resources/list to see the files of this session.",
+		URITemplate: resourceScheme + "://{mount}/{+path}",
+	}, func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		sessionCtx, err := getSessionFromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		fsPath, err := resourcePath(sessionCtx, req.Params.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := sessionCtx.SandboxFS.ReadBytes(fsPath, 0, 0)
+		if err != nil {
+			return nil, mcp.ResourceNotFoundError(req.Params.URI)
+		}
+
+		contents := &mcp.ResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: resourceMimeType(fsPath),
+		}
+		if isTextMimeType(contents.MIMEType) && utf8.Valid(data) {
+			contents.Text = string(data)
+		} else {
+			contents.Blob = data
+		}
+
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{contents}}, nil
+	})
+}
+
+// NotifyResourceUpdates sends notifications/resources/updated to subscribers when code execution
+// or the file tools change a file. Updates go through the MCP server of each session mounting
+// the changed directory. Call once at startup, not per MCP server.
+func NotifyResourceUpdates(sessionMgr *session.Manager) {
+	sessionMgr.SetFileChangedCallback(func(sessionCtx *session.SessionContext, dirName, relPath string) {
+		cfg, ok := sessionCtx.SandboxFS.GetDirectoryConfig(dirName)
+		if !ok {
+			return
+		}
+
+		params := &mcp.ResourceUpdatedNotificationParams{
+			URI:  resourceURI(dirName, relPath),
+			Meta: mcp.Meta{resourceOriginKey: directoryID(cfg.Root)},
+		}
+
+		// Notify asynchronously so slow clients never stall code execution
+		server := sessionCtx.Server()
+		go func() {
+			if err := server.ResourceUpdated(context.Background(), params); err != nil {
+				slog.Warn("Failed to notify resource update", logging.SessionKey, sessionCtx.SessionID, "uri", params.URI, "error", err)
+			}
+		}()
+	})
+}
+
+// createResourceListMiddleware creates middleware that answers resources/list with the
+// files of the session's sandbox filesystem. Must run after session injection.
+func createResourceListMiddleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != "resources/list" {
+				return next(ctx, method, req)
+			}
+
+			sessionCtx, err := getSessionFromContext(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			result := &mcp.ListResourcesResult{Resources: []*mcp.Resource{}}
+			if sessionCtx.SandboxFS == nil {
+				return result, nil
+			}
+
+			for _, dirName := range sessionCtx.SandboxFS.GetDirectories() {
+				err := sessionCtx.SandboxFS.WalkFiles("./"+dirName, func(relPath string, info sandbox.FileInfo) error {
+					result.Resources = append(result.Resources, &mcp.Resource{
+						URI:      resourceURI(dirName, relPath),
+						Name:     dirName + "/" + relPath,
+						MIMEType: resourceMimeType(relPath),
+						Size:     info.Size,
+					})
+					return nil
+				})
+				if err != nil {
+					slog.WarnContext(ctx, "Failed to list resources", "directory", dirName, "error", err)
+				}
+			}
+
+			return result, nil
+		}
+	}
+}
+
+// createResourceUpdateFilterMiddleware creates sending middleware that drops resource update
+// notifications for directories the receiving session does not mount
+func createResourceUpdateFilterMiddleware(sessionMgr *session.Manager) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != "notifications/resources/updated" {
+				return next(ctx, method, req)
+			}
+
+			params, ok := req.GetParams().(*mcp.ResourceUpdatedNotificationParams)
+			if !ok {
+				return next(ctx, method, req)
+			}
+			origin, _ := params.Meta[resourceOriginKey].(string)
+			if origin == "" {
+				return next(ctx, method, req)
+			}
+
+			sessionCtx := sessionMgr.GetSession(req.GetSession().ID())
+			if sessionCtx == nil || sessionCtx.SandboxFS == nil {
+				return nil, nil
+			}
+
+			u, err := url.Parse(params.URI)
+			if err != nil {
+				return nil, nil
+			}
+			cfg, ok := sessionCtx.SandboxFS.GetDirectoryConfig(u.Host)
+			if !ok || directoryID(cfg.Root) != origin {
+				return nil, nil
+			}
+
+			return next(ctx, method, req)
+		}
+	}
+}
+
+// subscribeResource validates a resources/subscribe request.
+// Subscriptions are tracked by the SDK; updates are filtered per session when sent.
+func subscribeResource(ctx context.Context, req *mcp.SubscribeRequest) error {
+	sessionCtx, err := getSessionFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = resourcePath(sessionCtx, req.Params.URI)
+	return err
+}
+
+// unsubscribeResource accepts a resources/unsubscribe request
+func unsubscribeResource(ctx context.Context, req *mcp.UnsubscribeRequest) error {
+	return nil
+}
+
+// resourceURI builds the resource URI of a file in a mounted directory
+func resourceURI(dirName, relPath string) string {
+	u := url.URL{Scheme: resourceScheme, Host: dirName, Path: "/" + relPath}
+	return u.String()
+}
+
+// resourcePath converts a resource URI into a sandbox filesystem path
+func resourcePath(sessionCtx *session.SessionContext, uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != resourceScheme {
+		return "", fmt.Errorf("invalid resource URI %q", uri)
+	}
+
+	relPath := strings.TrimPrefix(u.Path, "/")
+	if relPath == "" {
+		return "", fmt.Errorf("resource URI %q does not name a file", uri)
+	}
+
+	return sandboxPath(sessionCtx, "/"+u.Host+"/"+relPath)
+}
+
+// resourceMimeType guesses the mime type of a file from its extension
+func resourceMimeType(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if mimeType, ok := extraMimeTypes[ext]; ok {
+		return mimeType
+	}
+	if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+		if base, _, err := mime.ParseMediaType(mimeType); err == nil {
+			return base
+		}
+		return mimeType
+	}
+	return "application/octet-stream"
+}
+
+// isTextMimeType reports whether content of a mime type should be returned as text
+func isTextMimeType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") || textMimeTypes[mimeType] ||
+		strings.HasSuffix(mimeType, "+json") || strings.HasSuffix(mimeType, "jsonl") || strings.HasSuffix(mimeType, "ndjson")
+}
+
+// directoryID returns an opaque identifier for a directory root, so roots are never sent to clients
+func directoryID(root string) string {
+	sum := sha256.Sum256([]byte(root))
+	return hex.EncodeToString(sum[:8])
+}
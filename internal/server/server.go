@@ -3,26 +3,36 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/yousuf/runbyte/internal/bundler"
+	"github.com/yousuf/runbyte/internal/codegen"
+	"github.com/yousuf/runbyte/internal/config"
 	"github.com/yousuf/runbyte/internal/sandbox"
 	"github.com/yousuf/runbyte/internal/session"
 	"github.com/yousuf/runbyte/internal/strutil"
+	"github.com/yousuf/runbyte/internal/telemetry"
+	"github.com/yousuf/runbyte/internal/toolsearch"
+	"github.com/yousuf/runbyte/internal/typecheck"
 )
 
 // ExecuteCodeArgs represents the arguments for the execute_code tool
 type ExecuteCodeArgs struct {
-	Code string `json:"code" jsonschema:"TypeScript code to execute in sandbox"`
+	Code  string `json:"code" jsonschema:"TypeScript code to execute in sandbox"`
+	Trace string `json:"trace,omitempty" jsonschema:"Detail of the tool call trace returned with the result: 'off', 'summary' (default, shortened arguments) or 'full' (complete arguments)"`
 }
 
 // ListDirectoryArgs represents the arguments for the list_directory tool
 type ListDirectoryArgs struct {
 	Path             string `json:"path" jsonschema:"Path to directory (e.g., '/', '/servers', '/servers/github'). Defaults to '/' if not provided."`
-	WithDescriptions bool   `json:"withDescriptions,omitempty" jsonschema:"Include descriptions for functions (default: false)"`
+	WithDescriptions bool   `json:"withDescriptions,omitempty" jsonschema:"Include descriptions and behaviour annotations (readOnly, destructive, idempotent, openWorld) for functions (default: false)"`
 }
 
 // ReadFileArgs represents the arguments for the read_file tool
@@ -30,8 +40,31 @@ type ReadFileArgs struct {
 	Path string `json:"path" jsonschema:"Required. Path to file in virtual filesystem (e.g., '/servers/github/listRepos.ts', '/servers/github/index.ts')"`
 }
 
+// SearchToolsArgs represents the arguments for the search_tools tool
+type SearchToolsArgs struct {
+	Query  string `json:"query" jsonschema:"Required. What you want to do or keywords to look for (e.g., 'create issue', 'pagination', 'send slack message')"`
+	Server string `json:"server,omitempty" jsonschema:"Only search tools from this MCP server (e.g., 'github')"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"Maximum number of results (default: 10)"`
+	Mode   string `json:"mode,omitempty" jsonschema:"Ranking mode: 'keyword', 'semantic' or 'hybrid' (default: hybrid when semantic search is enabled, otherwise keyword)"`
+}
+
+// executionStatsKey is the execute_code result _meta key holding execution timing statistics
+const executionStatsKey = "runbyte/stats"
+
+// ExecutionReport is the structured content of execute_code results, including failed ones
+type ExecutionReport struct {
+	Result json.RawMessage      `json:"result,omitempty"` // Return value of exec(), as in the text content
+	Error  string               `json:"error,omitempty"`
+	Trace  []sandbox.TraceEntry `json:"trace"` // MCP tool calls made by the code, in call order
+}
+
+// defaultSearchLimit is the number of search_tools results returned when no limit is given
+const defaultSearchLimit = 10
+
 // NewMcpServer creates and configures the MCP server
 func NewMcpServer(wasmBytes []byte, sessionMgr *session.Manager) *mcp.Server {
+	executionTimeout := fmt.Sprintf("%d seconds", int(sessionMgr.ExecutionTimeout().Seconds()))
+
 	server := mcp.NewServer(&mcp.Implementation{
 		Name:    "runbyte",
 		Version: "1.0.0",
@@ -51,7 +84,9 @@ All MCP servers are exposed as TypeScript modules in a virtual filesystem. Explo
 │   ├── filesystem/      File operations
 │   ├── slack/           Slack integrations
 │   └── ...              (All configured MCP servers)
-└── workspace/           Your persistent workspace (read/write)
+├── workspace/           Your persistent workspace (read/write)
+├── cache/               Cache for the current session (read/write)
+└── temp/                Scratch space, cleared after every execute_code call
 
 ## Efficient Discovery Pattern
 
@@ -62,6 +97,10 @@ Don't load all tools upfront. Discover on-demand:
 3. **Explore specific server**: list_directory({ path: "/servers/github" })
 4. **Read tool signatures**: read_file({ path: "/servers/github/createIssue.ts" })
 
+When you know what you want to do but not which tool does it, search instead of browsing:
+
+    search_tools({ query: "create issue" })
+
 Each tool file contains complete TypeScript types, JSDoc, and function signatures. Read only what you need.
 
 ## Code Execution Pattern
@@ -138,16 +177,14 @@ IMPORTANT: Since most MCP server tools do not explicitly document their output s
 import * as github from './servers/github';
 import * as filesystem from './servers/filesystem';
 import * as fs from '@runbyte/fs';
+import * as kv from '@runbyte/kv';
 
 async function exec() {
   // Check if we have cached results from previous run
-  try {
-    const cached = fs.readFile("./workspace/metrics.json");
-    const data = JSON.parse(cached);
-    if (Date.now() - data.timestamp < 3600000) { // 1 hour
-      return data.metrics;
-    }
-  } catch {}
+  const cached = await kv.get("metrics");
+  if (cached) {
+    return cached;
+  }
   
   // No cache or expired - fetch and compute
   const repos = await github.listRepos({ owner: "myorg" });
@@ -164,20 +201,12 @@ async function exec() {
     ).length
   };
   
-  // Store for next time
-  fs.writeFile("./workspace/metrics.json", JSON.stringify({
-    timestamp: Date.now(),
-    metrics
-  }));
+  // Store for next time (expires after 1 hour)
+  await kv.set("metrics", metrics, { ttlMs: 3600000 });
   
   // Also append to history log
   const logEntry = ` + "`" + `[${new Date().toISOString()}] Metrics: ${JSON.stringify(metrics)}\n` + "`" + `;
-  try {
-    const existing = fs.readFile("./workspace/metrics-history.log");
-    fs.writeFile("./workspace/metrics-history.log", existing + logEntry);
-  } catch {
-    fs.writeFile("./workspace/metrics-history.log", logEntry);
-  }
+  await fs.appendFile("./workspace/metrics-history.log", logEntry);
   
   return metrics;
 }
@@ -186,8 +215,12 @@ async function exec() {
 ## Available Tools
 
 - **list_directory** - Explore the virtual filesystem to discover MCP servers and tools
+- **search_tools** - Find tools by keywords across all servers, ranked by relevance
 - **read_file** - Read tool definitions, workspace files, or cached data
 - **execute_code** - Run your TypeScript code with automatic bundling
+- **write_file** / **delete_file** / **move_file** - Upload (text or base64), remove and move files in mounted directories
+- **glob** / **grep** - Find files by name or content, recursively, in mounted directories and /servers
+- **list_versions** / **restore_version** - Roll back workspace files overwritten or deleted by code execution
 
 ## Filesystem API (@runbyte/fs)
 
@@ -207,6 +240,45 @@ const files = fs.listFiles("./workspace");
 
 // Delete files from workspace
 fs.deleteFile("./workspace/old-data.json");
+
+// Append to a log without rewriting it
+await fs.appendFile("./workspace/run.log", "step 1 done\n");
+
+// Binary files and large files in chunks
+const bytes = await fs.readBytes("./workspace/image.png");
+const firstKb = await fs.readFile("./workspace/big.csv", { offset: 0, length: 1024 });
+await fs.writeBytes("./workspace/copy.png", bytes);
+
+// File info and organization
+const info = await fs.stat("./workspace/data.json"); // { size, mtime, isDirectory, ... }
+await fs.mkdir("./workspace/reports");
+await fs.rename("./temp/draft.json", "./workspace/reports/final.json");
+await fs.copy("./workspace/data.json", "./workspace/data.backup.json");
+
+// Find files by name or content, recursively (also searches /servers)
+const reports = await fs.glob("*.md", { path: "./workspace/reports" }); // [{ path, size, mtime }]
+const hits = await fs.grep("pagination", { path: "/servers", include: "*.ts", ignoreCase: true }); // [{ path, line, text }]
+
+// Read-modify-write safely when other executions may update the same file
+await fs.withLock("./workspace/counter.json", async () => {
+  const counter = await fs.readJSON("./workspace/counter.json");
+  counter.value++;
+  await fs.writeJSON("./workspace/counter.json", counter);
+});
+` + "```" + `
+
+## Key-Value API (@runbyte/kv)
+
+For structured state (caches, counters, progress markers), prefer the key-value store over JSON files:
+
+` + "```typescript" + `
+import * as kv from '@runbyte/kv';
+
+await kv.set("cache:repos", repos, { ttlMs: 5 * 60 * 1000 }); // expires after 5 minutes
+const cached = await kv.get("cache:repos");                   // undefined if missing or expired
+const runs = await kv.increment("stats:runs");                 // atomic counter
+const entries = await kv.list("cache:");                       // [{ key, value, expiresAt }]
+await kv.delete("cache:repos");
 ` + "```" + `
 
 ## Key Principles
@@ -222,14 +294,26 @@ fs.deleteFile("./workspace/old-data.json");
 - All paths start with '/'
 - Namespace imports work best: ` + "`" + `import * as github from './servers/github'` + "`" + `
 - exec() can be sync or async
-- Execution timeout: 30 seconds
+- Execution timeout: ` + executionTimeout + `
 - Automatic bundling with full TypeScript support
 - Each tool file has complete type definitions and JSDoc
 `,
+		SubscribeHandler:   subscribeResource,
+		UnsubscribeHandler: unsubscribeResource,
 	})
 
-	server.AddReceivingMiddleware(createSessionInjectionMiddleware(sessionMgr))
+	// Middleware added later runs first, so resource listing and logging level
+	// propagation see the injected session. The SDK declares the logging capability
+	// and keeps the level each client sets with logging/setLevel.
+	server.AddReceivingMiddleware(createResourceListMiddleware())
+	server.AddReceivingMiddleware(createLoggingLevelMiddleware())
+	server.AddReceivingMiddleware(createSessionInjectionMiddleware(server, sessionMgr))
 	server.AddReceivingMiddleware(createLoggingMiddleware())
+	server.AddReceivingMiddleware(createTracingMiddleware())
+	server.AddSendingMiddleware(createResourceUpdateFilterMiddleware(sessionMgr))
+
+	// Publish sandbox filesystem files as resources
+	addFileResources(server)
 
 	// Register execute_code tool
 	mcp.AddTool(server, &mcp.Tool{
@@ -307,49 +391,126 @@ Filesystem API for workflows:
     fs.deleteFile("./workspace/old.json");
 
 Sandbox environment:
-- Execution timeout: 30 seconds
+- Execution timeout: ` + executionTimeout + `
 - Automatic bundling with TypeScript support
 - No Node.js built-ins or DOM APIs
-- All MCP tool calls are async
+- fetch() reaches only hosts allowed by the server configuration
+- setTimeout, setInterval and sleep(ms) wait in real time, within the execution timeout
+- All MCP tool calls are async and run concurrently; use Promise.all to fan out
+- batch([{server, tool, args}], {concurrency, dedupe}) makes many calls at once and resolves to [{ok, value} | {ok, error}] in order
+- Tool functions accept { cache: false } as a last argument to bypass the result cache, when one is configured
 `,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args ExecuteCodeArgs) (*mcp.CallToolResult, any, error) {
 		sessionCtx, err := getSessionFromContext(ctx)
 		if err != nil {
 			return nil, nil, err
 		}
+		if err := config.ValidateTraceVerbosity(args.Trace); err != nil {
+			return nil, nil, err
+		}
+
+		codeWithCaller := fmt.Sprintf(`%s
+exec();
+`, args.Code)
 
-		// Step 1: Bundle the code using session's bundle directory
+		start := time.Now()
+		outcome := outcomeOK
+		defer func() {
+			executions.Inc(outcome)
+			executionSeconds.Observe(time.Since(start).Seconds(), outcome)
+		}()
+
+		// Step 1: Type-check the code against the generated libraries (if enabled)
+		// Type checking counts towards the execution timeout, so the sandbox gets what is left
+		executionBudget := sessionMgr.ExecutionTimeout()
+		if checker := typecheck.Default(); checker != nil {
+			checkStart := time.Now()
+			checkCtx, span := telemetry.Start(ctx, "typecheck", telemetry.KindInternal)
+			checkCtx, cancelCheck := context.WithTimeout(checkCtx, executionBudget)
+			err := typeCheck(checkCtx, checker, sessionCtx.BundleDir, codeWithCaller)
+			cancelCheck()
+			span.RecordError(err)
+			span.End()
+			if err != nil {
+				outcome = outcomeTypeError
+				return nil, nil, err
+			}
+			executionBudget -= time.Since(checkStart)
+		}
+
+		// Step 2: Bundle the code using session's bundle directory
 		b, err := bundler.New()
 		if err != nil {
+			outcome = outcomeBundleError
 			return nil, nil, fmt.Errorf("failed to create bundler: %w", err)
 		}
 
-		codeWithCaller := fmt.Sprintf(`%s
-exec();
-`, args.Code)
+		bundleStart := time.Now()
+		_, span := telemetry.Start(ctx, "bundle", telemetry.KindInternal)
 		bundledCode, sourceMap, err := b.Bundle(sessionCtx.BundleDir, codeWithCaller)
+		span.SetAttr("runbyte.bundle.bytes", len(bundledCode))
+		span.RecordError(err)
+		span.End()
+		bundleSeconds.Observe(time.Since(bundleStart).Seconds())
 		if err != nil {
+			outcome = outcomeBundleError
 			return nil, nil, fmt.Errorf("bundling failed: %w", err)
 		}
 
-		// Step 2: Create sandbox with filesystem access
-		sb, err := sandbox.NewSandbox(ctx, wasmBytes, sessionCtx.ClientHub, sessionCtx.SandboxFS)
+		// Step 3: Create sandbox with filesystem access, bounded by the execution deadline
+		execCtx, cancel := context.WithTimeout(ctx, executionBudget)
+		defer cancel()
+
+		opts := sessionMgr.SandboxOptions(args.Trace)
+		opts.Log = func(level mcp.LoggingLevel, logger, message string) {
+			sessionCtx.LogToClient(ctx, level, logger, message)
+		}
+		_, span = telemetry.Start(execCtx, "sandbox.create", telemetry.KindInternal)
+		sb, err := sandbox.NewSandbox(execCtx, wasmBytes, sessionCtx.ClientHub, sessionCtx.SandboxFS, sessionCtx.KV, sessionCtx.Fetcher, opts)
+		span.RecordError(err)
+		span.End()
 		if err != nil {
+			outcome = outcomeSandboxError
 			return nil, nil, fmt.Errorf("failed to create sandbox: %w", err)
 		}
 		defer sb.Close()
 
-		// Step 3: Execute bundled code
+		// Step 4: Execute bundled code. Failed executions still report the tool
+		// calls they made, so the agent knows which side effects already happened.
+		_, span = telemetry.Start(execCtx, "sandbox.execute", telemetry.KindInternal)
 		result, err := sb.ExecuteCode(bundledCode, sourceMap)
-		if err != nil {
-			return nil, nil, fmt.Errorf("execution failed: %w", err)
-		}
+		span.RecordError(err)
+		span.End()
 
-		return &mcp.CallToolResult{
+		res := &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{Text: result},
 			},
-		}, nil, nil
+			Meta: mcp.Meta{executionStatsKey: sb.Stats()},
+		}
+		if err != nil {
+			res.Content = []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("execution failed: %v", err)},
+			}
+			res.IsError = true
+
+			outcome = outcomeRuntimeError
+			if errors.Is(execCtx.Err(), context.DeadlineExceeded) {
+				outcome = outcomeTimeout
+			}
+		}
+
+		if opts.Trace.Verbosity != sandbox.TraceOff {
+			report := ExecutionReport{Trace: sb.Trace()}
+			if err != nil {
+				report.Error = err.Error()
+			} else if json.Valid([]byte(result)) {
+				report.Result = json.RawMessage(result)
+			}
+			res.StructuredContent = report
+		}
+
+		return res, nil, nil
 	})
 
 	// Register list_directory tool
@@ -381,7 +542,7 @@ exec();
 					if i == len(dirs)-1 {
 						prefix = "└──"
 					}
-					output.WriteString(fmt.Sprintf("%s %s/\n", prefix, dir))
+					output.WriteString(fmt.Sprintf("%s %s/%s\n", prefix, dir, mountLabel(sessionCtx.SandboxFS, dir)))
 				}
 			}
 
@@ -430,11 +591,19 @@ exec();
 				}
 
 				funcName := strutil.ToCamelCase(tool.Name)
-				if args.WithDescriptions && tool.Description != "" {
-					output.WriteString(fmt.Sprintf("%s %s.ts - %s\n", prefix, funcName, tool.Description))
-				} else {
+				if !args.WithDescriptions {
 					output.WriteString(fmt.Sprintf("%s %s.ts\n", prefix, funcName))
+					continue
+				}
+
+				line := fmt.Sprintf("%s %s.ts", prefix, funcName)
+				if tool.Description != "" {
+					line += " - " + tool.Description
+				}
+				if labels := codegen.ToolAnnotationLabels(tool); len(labels) > 0 {
+					line += fmt.Sprintf(" [%s]", strings.Join(labels, ", "))
 				}
+				output.WriteString(line + "\n")
 			}
 			output.WriteString("└── index.ts\n")
 			return &mcp.CallToolResult{
@@ -456,12 +625,21 @@ exec();
 						return nil, nil, fmt.Errorf("failed to list directory '/%s': %w", path, err)
 					}
 
-					output.WriteString(fmt.Sprintf("/%s/\n", path))
+					output.WriteString(fmt.Sprintf("/%s/", path))
+					if path == dir {
+						output.WriteString(mountUsage(sessionCtx, dir))
+					}
+					output.WriteString("\n")
 					for i, file := range files {
 						prefix := "├──"
 						if i == len(files)-1 {
 							prefix = "└──"
 						}
+						if !strings.HasSuffix(file, "/") {
+							if info, err := sessionCtx.SandboxFS.Stat(fsPath + "/" + file); err == nil {
+								file += fmt.Sprintf(" (%s)", formatBytes(info.Size))
+							}
+						}
 						output.WriteString(fmt.Sprintf("%s %s\n", prefix, file))
 					}
 
@@ -531,5 +709,178 @@ exec();
 		return nil, nil, fmt.Errorf("file '/%s' not found - path must start with 'servers/', 'workspace/'", path)
 	})
 
+	// Register search_tools tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "search_tools",
+		Description: "Search all MCP server tools by keywords or intent. Matches tool names, descriptions and argument names/descriptions, tolerating typos and partial words. When semantic search is enabled, results also rank tools by meaning. Returns the best matches with their file path and TypeScript signature.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args SearchToolsArgs) (*mcp.CallToolResult, any, error) {
+		sessionCtx, err := getSessionFromContext(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if strings.TrimSpace(args.Query) == "" {
+			return nil, nil, fmt.Errorf("query is required")
+		}
+
+		if args.Server != "" {
+			if _, ok := sessionCtx.ClientHub.ServerTools(args.Server); !ok {
+				return nil, nil, fmt.Errorf("server %q not found. Available servers: %v",
+					args.Server, sessionCtx.ClientHub.Servers())
+			}
+		}
+
+		limit := args.Limit
+		if limit <= 0 {
+			limit = defaultSearchLimit
+		}
+
+		mode := toolsearch.SearchMode(args.Mode)
+		switch mode {
+		case "":
+			mode = toolsearch.ModeKeyword
+			if sessionCtx.ToolIndex.SemanticEnabled() {
+				mode = toolsearch.ModeHybrid
+			}
+		case toolsearch.ModeKeyword, toolsearch.ModeSemantic, toolsearch.ModeHybrid:
+			if mode != toolsearch.ModeKeyword && !sessionCtx.ToolIndex.SemanticEnabled() {
+				return nil, nil, fmt.Errorf("%s search is not enabled - set search.semantic in runbyte.json", mode)
+			}
+		default:
+			return nil, nil, fmt.Errorf("invalid mode %q (must be keyword, semantic, or hybrid)", args.Mode)
+		}
+
+		results, err := sessionCtx.ToolIndex.Search(ctx, toolsearch.SearchOptions{
+			Query:  args.Query,
+			Server: args.Server,
+			Limit:  limit,
+			Mode:   mode,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("search failed: %w", err)
+		}
+
+		var output bytes.Buffer
+		if len(results) == 0 {
+			output.WriteString(fmt.Sprintf("No tools found matching %q. Try different keywords or browse with list_directory.\n", args.Query))
+		} else {
+			output.WriteString(fmt.Sprintf("Found %d tool(s) matching %q:\n", len(results), args.Query))
+		}
+
+		for i, result := range results {
+			output.WriteString(fmt.Sprintf("\n%d. %s.%s", i+1, result.ServerName, result.FuncName))
+			if len(result.Annotations) > 0 {
+				output.WriteString(fmt.Sprintf(" [%s]", strings.Join(result.Annotations, ", ")))
+			}
+			output.WriteString(fmt.Sprintf(" (score: %.2f)\n", result.Score))
+			output.WriteString(fmt.Sprintf("   %s\n", result.Path()))
+			output.WriteString(fmt.Sprintf("   %s\n", result.Signature))
+			if summary := summarize(result.Title, result.Description); summary != "" {
+				output.WriteString(fmt.Sprintf("   %s\n", summary))
+			}
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: output.String()},
+			},
+		}, nil, nil
+	})
+
+	// Register workspace file management tools
+	addWorkspaceTools(server)
+
 	return server
 }
+
+// mountLabel describes the access mode and lifecycle of a filesystem directory
+func mountLabel(sfs *sandbox.SandboxFileSystem, name string) string {
+	cfg, ok := sfs.GetDirectoryConfig(name)
+	if !ok {
+		return ""
+	}
+
+	var labels []string
+	if cfg.ReadOnly {
+		labels = append(labels, "read-only")
+	}
+	switch cfg.Lifecycle {
+	case sandbox.LifecyclePersistent:
+		labels = append(labels, "persistent")
+	case sandbox.LifecycleExecution:
+		labels = append(labels, "cleared after each execution")
+	}
+
+	if len(labels) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", strings.Join(labels, ", "))
+}
+
+// mountUsage describes the scope and usage of a filesystem directory for its listing header
+func mountUsage(sessionCtx *session.SessionContext, name string) string {
+	cfg, ok := sessionCtx.SandboxFS.GetDirectoryConfig(name)
+	if !ok {
+		return ""
+	}
+	stats := sessionCtx.SandboxFS.GetStats()[name]
+
+	usage := fmt.Sprintf("%d files, %s of %s used", stats.FileCount, formatBytes(stats.TotalBytes), formatBytes(cfg.MaxTotalSize))
+	if name == "workspace" && sessionCtx.WorkspaceScope != "" {
+		usage = fmt.Sprintf("%s scope, %s", sessionCtx.WorkspaceScope, usage)
+	}
+	return fmt.Sprintf(" (%s)", usage)
+}
+
+// formatBytes formats a byte count using binary units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// summarize returns a short one-line summary from a tool's title and description
+func summarize(title, description string) string {
+	const maxLen = 160
+
+	summary := strings.Join(strings.Fields(description), " ")
+	if title != "" {
+		if summary == "" {
+			summary = title
+		} else {
+			summary = title + ": " + summary
+		}
+	}
+
+	if runes := []rune(summary); len(runes) > maxLen {
+		summary = string(runes[:maxLen-3]) + "..."
+	}
+	return summary
+}
+
+// typeCheck type-checks user code and returns an error listing the diagnostics.
+// If the checker itself fails, type checking is skipped so execution can proceed.
+// Checks cancelled or running past the deadline of ctx fail instead.
+func typeCheck(ctx context.Context, checker *typecheck.Checker, bundleDir, code string) error {
+	diagnostics, err := checker.Check(ctx, bundleDir, code)
+	if err != nil && ctx.Err() != nil {
+		return err
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Type check skipped", "error", err)
+		return nil
+	}
+
+	if len(diagnostics) > 0 {
+		return fmt.Errorf("type check failed\nerrors:\n%s", typecheck.FormatDiagnostics(diagnostics))
+	}
+
+	return nil
+}
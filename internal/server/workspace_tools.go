@@ -0,0 +1,348 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yousuf/runbyte/internal/sandbox"
+	"github.com/yousuf/runbyte/internal/session"
+)
+
+// GlobArgs represents the arguments for the glob tool
+type GlobArgs struct {
+	Pattern  string `json:"pattern" jsonschema:"Glob pattern. Without '/' it matches file names at any depth (e.g., '*.md'); with '/' it matches paths relative to path, where '**' matches any number of directories (e.g., 'reports/**/*.json'). Defaults to all files."`
+	Path     string `json:"path,omitempty" jsonschema:"Directory to search (e.g., '/workspace', '/servers/github'). Defaults to all mounted directories and /servers."`
+	MaxDepth int    `json:"maxDepth,omitempty" jsonschema:"Maximum directory depth below path (1 searches only path itself; default: unlimited)"`
+	Limit    int    `json:"limit,omitempty" jsonschema:"Maximum number of files (default: 100, max: 1000)"`
+}
+
+// GrepArgs represents the arguments for the grep tool
+type GrepArgs struct {
+	Query      string `json:"query" jsonschema:"Required. Regular expression to search for (RE2 syntax, e.g., 'pagination|cursor')"`
+	Path       string `json:"path,omitempty" jsonschema:"Directory to search (e.g., '/workspace', '/servers/github'). Defaults to all mounted directories and /servers."`
+	Include    string `json:"include,omitempty" jsonschema:"Only search files matching this glob (e.g., '*.ts')"`
+	IgnoreCase bool   `json:"ignoreCase,omitempty" jsonschema:"Match case-insensitively (default: false)"`
+	MaxDepth   int    `json:"maxDepth,omitempty" jsonschema:"Maximum directory depth below path (1 searches only path itself; default: unlimited)"`
+	Limit      int    `json:"limit,omitempty" jsonschema:"Maximum number of matching lines (default: 100, max: 1000)"`
+}
+
+// ListVersionsArgs represents the arguments for the list_versions tool
+type ListVersionsArgs struct {
+	Path string `json:"path" jsonschema:"Required. Path to a file in a mounted directory (e.g., '/workspace/report.json'). Deleted files keep their versions."`
+}
+
+// RestoreVersionArgs represents the arguments for the restore_version tool
+type RestoreVersionArgs struct {
+	Path    string `json:"path" jsonschema:"Required. Path to a file in a mounted directory (e.g., '/workspace/report.json')"`
+	Version string `json:"version" jsonschema:"Required. Version ID returned by list_versions"`
+}
+
+// WriteFileArgs represents the arguments for the write_file tool
+type WriteFileArgs struct {
+	Path     string `json:"path" jsonschema:"Required. Path to the file in a mounted directory (e.g., '/workspace/input.csv'). Parent directories are created."`
+	Content  string `json:"content" jsonschema:"Required. File content, as text or base64 (see encoding)"`
+	Encoding string `json:"encoding,omitempty" jsonschema:"Encoding of content: 'utf8' for text or 'base64' for binary files (default: utf8)"`
+}
+
+// DeleteFileArgs represents the arguments for the delete_file tool
+type DeleteFileArgs struct {
+	Path string `json:"path" jsonschema:"Required. Path to a file or empty directory in a mounted directory (e.g., '/workspace/old.json')"`
+}
+
+// MoveFileArgs represents the arguments for the move_file tool
+type MoveFileArgs struct {
+	Source      string `json:"source" jsonschema:"Required. Path of the file to move (e.g., '/temp/draft.md')"`
+	Destination string `json:"destination" jsonschema:"Required. New path, possibly in another mounted directory (e.g., '/workspace/report.md'). An existing file is replaced."`
+}
+
+// addWorkspaceTools registers the tools that manage files in the sandbox filesystem
+func addWorkspaceTools(server *mcp.Server) {
+	// Register write_file tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "write_file",
+		Description: "Write a file to a mounted sandbox directory, e.g. to provide user input such as a pasted CSV to code executed later. Use encoding 'base64' to upload binary files. Replaces existing files (the previous content is kept as a version).",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args WriteFileArgs) (*mcp.CallToolResult, any, error) {
+		sessionCtx, err := getSessionFromContext(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fsPath, err := sandboxPath(sessionCtx, args.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var data []byte
+		switch args.Encoding {
+		case "", sandbox.EncodingUTF8:
+			data = []byte(args.Content)
+		case sandbox.EncodingBase64:
+			data, err = base64.StdEncoding.DecodeString(args.Content)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid base64 content: %w", err)
+			}
+		default:
+			return nil, nil, fmt.Errorf("invalid encoding %q (must be utf8 or base64)", args.Encoding)
+		}
+
+		if err := sessionCtx.SandboxFS.WriteBytes(fsPath, data); err != nil {
+			return nil, nil, fmt.Errorf("failed to write '%s': %w", args.Path, err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Wrote %s (%s)\n", args.Path, formatBytes(int64(len(data))))},
+			},
+		}, nil, nil
+	})
+
+	// Register delete_file tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "delete_file",
+		Description: "Delete a file or empty directory from a mounted sandbox directory. Deleted files can be recovered with list_versions and restore_version when versioning is enabled.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args DeleteFileArgs) (*mcp.CallToolResult, any, error) {
+		sessionCtx, err := getSessionFromContext(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fsPath, err := sandboxPath(sessionCtx, args.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := sessionCtx.SandboxFS.DeleteFile(fsPath); err != nil {
+			return nil, nil, fmt.Errorf("failed to delete '%s': %w", args.Path, err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Deleted %s\n", args.Path)},
+			},
+		}, nil, nil
+	})
+
+	// Register move_file tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "move_file",
+		Description: "Move or rename a file within or between mounted sandbox directories (e.g., keep a result from /temp by moving it to /workspace). Directories can only be renamed within the same mount.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args MoveFileArgs) (*mcp.CallToolResult, any, error) {
+		sessionCtx, err := getSessionFromContext(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		srcPath, err := sandboxPath(sessionCtx, args.Source)
+		if err != nil {
+			return nil, nil, err
+		}
+		dstPath, err := sandboxPath(sessionCtx, args.Destination)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := sessionCtx.SandboxFS.Rename(srcPath, dstPath); err != nil {
+			return nil, nil, fmt.Errorf("failed to move '%s' to '%s': %w", args.Source, args.Destination, err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Moved %s to %s\n", args.Source, args.Destination)},
+			},
+		}, nil, nil
+	})
+
+	// Register glob tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "glob",
+		Description: "Find files by glob pattern, recursively, across mounted directories (e.g., /workspace) and the generated server libraries in /servers. Returns paths with sizes and modification times. Example: pattern '*.md' with path '/workspace' finds reports written earlier.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args GlobArgs) (*mcp.CallToolResult, any, error) {
+		sessionCtx, err := getSessionFromContext(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if sessionCtx.SandboxFS == nil {
+			return nil, nil, fmt.Errorf("sandbox filesystem is not available")
+		}
+
+		matches, truncated, err := sessionCtx.SandboxFS.Glob(sandbox.SearchRequest{
+			Path:     args.Path,
+			Pattern:  args.Pattern,
+			MaxDepth: args.MaxDepth,
+			Limit:    args.Limit,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("glob failed: %w", err)
+		}
+
+		pattern := args.Pattern
+		if pattern == "" {
+			pattern = "*"
+		}
+
+		var output bytes.Buffer
+		if len(matches) == 0 {
+			output.WriteString(fmt.Sprintf("No files found matching %q in %s\n", pattern, searchScope(args.Path)))
+		} else {
+			output.WriteString(fmt.Sprintf("Found %d file(s) matching %q in %s:\n", len(matches), pattern, searchScope(args.Path)))
+		}
+		for _, match := range matches {
+			modified := time.UnixMilli(match.ModTime).UTC().Format(time.RFC3339)
+			output.WriteString(fmt.Sprintf("%s  %s  %s\n", match.Path, formatBytes(match.Size), modified))
+		}
+		if truncated {
+			output.WriteString(fmt.Sprintf("(results limited to %d; narrow the pattern or path, or raise limit)\n", len(matches)))
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: output.String()},
+			},
+		}, nil, nil
+	})
+
+	// Register grep tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "grep",
+		Description: "Search file contents with a regular expression, recursively, across mounted directories (e.g., /workspace) and the generated server libraries in /servers. Returns matching lines as path:line: text. Example: query 'pagination' with path '/servers' finds tools that paginate.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args GrepArgs) (*mcp.CallToolResult, any, error) {
+		sessionCtx, err := getSessionFromContext(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if sessionCtx.SandboxFS == nil {
+			return nil, nil, fmt.Errorf("sandbox filesystem is not available")
+		}
+
+		matches, truncated, err := sessionCtx.SandboxFS.Grep(sandbox.SearchRequest{
+			Path:       args.Path,
+			Pattern:    args.Include,
+			Query:      args.Query,
+			IgnoreCase: args.IgnoreCase,
+			MaxDepth:   args.MaxDepth,
+			Limit:      args.Limit,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("grep failed: %w", err)
+		}
+
+		var output bytes.Buffer
+		if len(matches) == 0 {
+			output.WriteString(fmt.Sprintf("No lines found matching %q in %s\n", args.Query, searchScope(args.Path)))
+		} else {
+			output.WriteString(fmt.Sprintf("Found %d line(s) matching %q in %s:\n", len(matches), args.Query, searchScope(args.Path)))
+		}
+		for _, match := range matches {
+			output.WriteString(fmt.Sprintf("%s:%d: %s\n", match.Path, match.Line, match.Text))
+		}
+		if truncated {
+			output.WriteString(fmt.Sprintf("(results limited to %d; narrow the query, path or include pattern, or raise limit)\n", len(matches)))
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: output.String()},
+			},
+		}, nil, nil
+	})
+
+	// Register list_versions tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_versions",
+		Description: "List previous versions of a workspace file, newest first. A version is kept whenever a file is overwritten, replaced or deleted by code execution. Use restore_version to roll back.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args ListVersionsArgs) (*mcp.CallToolResult, any, error) {
+		sessionCtx, err := getSessionFromContext(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fsPath, err := sandboxPath(sessionCtx, args.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		versions, err := sessionCtx.SandboxFS.ListVersions(fsPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list versions of '%s': %w", args.Path, err)
+		}
+
+		var output bytes.Buffer
+		if len(versions) == 0 {
+			output.WriteString(fmt.Sprintf("No previous versions of %s\n", args.Path))
+		} else {
+			output.WriteString(fmt.Sprintf("%d previous version(s) of %s (newest first):\n", len(versions), args.Path))
+			for _, v := range versions {
+				savedAt := time.UnixMilli(v.SavedAt).UTC().Format(time.RFC3339)
+				output.WriteString(fmt.Sprintf("- %s  %s  %s\n", v.ID, savedAt, formatBytes(v.Size)))
+			}
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: output.String()},
+			},
+		}, nil, nil
+	})
+
+	// Register restore_version tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "restore_version",
+		Description: "Restore a previous version of a workspace file (also recreates deleted files). The current content is kept as a new version, so the restore can be undone.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args RestoreVersionArgs) (*mcp.CallToolResult, any, error) {
+		sessionCtx, err := getSessionFromContext(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if args.Version == "" {
+			return nil, nil, fmt.Errorf("version is required")
+		}
+
+		fsPath, err := sandboxPath(sessionCtx, args.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := sessionCtx.SandboxFS.RestoreVersion(fsPath, args.Version); err != nil {
+			return nil, nil, fmt.Errorf("failed to restore '%s': %w", args.Path, err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Restored %s to version %s\n", args.Path, args.Version)},
+			},
+		}, nil, nil
+	})
+}
+
+// sandboxPath converts a virtual filesystem path (e.g., '/workspace/data.json')
+// into a sandbox filesystem path, checking that it is inside a mounted directory
+func sandboxPath(sessionCtx *session.SessionContext, path string) (string, error) {
+	if sessionCtx.SandboxFS == nil {
+		return "", fmt.Errorf("sandbox filesystem is not available")
+	}
+
+	path = strings.TrimPrefix(path, "/")
+	for _, dir := range sessionCtx.SandboxFS.GetDirectories() {
+		if strings.HasPrefix(path, dir+"/") {
+			return "./" + path, nil
+		}
+	}
+
+	return "", fmt.Errorf("path '/%s' must be inside a mounted directory: %v", path, sessionCtx.SandboxFS.GetDirectories())
+}
+
+// searchScope describes the directory covered by a glob or grep call
+func searchScope(path string) string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "./"), "/")
+	path = strings.TrimSuffix(path, "/")
+	if path == "" {
+		return "all directories"
+	}
+	return "/" + path
+}
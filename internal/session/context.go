@@ -1,11 +1,16 @@
 package session
 
 import (
+	"context"
+	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/yousuf/runbyte/internal/client"
+	"github.com/yousuf/runbyte/internal/logging"
 	"github.com/yousuf/runbyte/internal/sandbox"
+	"github.com/yousuf/runbyte/internal/toolsearch"
 )
 
 // SessionContext represents a session with its associated resources and lifecycle.
@@ -14,18 +19,27 @@ type SessionContext struct {
 	SessionID      string
 	ClientHub      *client.McpClientHub
 	SandboxFS      *sandbox.SandboxFileSystem
+	KV             *sandbox.KVStore  // Key-value store kept in the workspace (nil if unavailable)
+	Fetcher        *sandbox.Fetcher  // Outbound HTTP for fetch() (nil unless hosts are allowed)
+	ToolIndex      *toolsearch.Index // Search index over ClientHub tools, rebuilt when tools change
+	Principal      string            // Authenticated principal that created the session (empty if unknown)
+	WorkspaceScope string            // Effective workspace scope (shared, session or principal)
 	CreatedAt      time.Time
 	BundleDir      string // Persistent directory for libs and bundling workspace
 	lastAccessedAt time.Time
+	server         *mcp.Server        // MCP server handling the session, notifying its resource subscribers
+	clientSession  *mcp.ServerSession // MCP session of the client, receiving forwarded log messages
 	mu             sync.RWMutex
 }
 
 // NewSessionContext creates a new session context.
-func NewSessionContext(sessionID string, clientHub *client.McpClientHub) *SessionContext {
+func NewSessionContext(sessionID, principal string, clientHub *client.McpClientHub) *SessionContext {
 	now := time.Now()
 	return &SessionContext{
 		SessionID:      sessionID,
+		Principal:      principal,
 		ClientHub:      clientHub,
+		ToolIndex:      toolsearch.NewIndex(),
 		CreatedAt:      now,
 		lastAccessedAt: now,
 	}
@@ -54,3 +68,42 @@ func (s *SessionContext) Age() time.Duration {
 func (s *SessionContext) IdleDuration() time.Duration {
 	return time.Since(s.LastAccessedAt())
 }
+
+// SetServer records the MCP server handling the session's requests (thread-safe)
+func (s *SessionContext) SetServer(server *mcp.Server) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.server = server
+}
+
+// Server returns the MCP server handling the session, or nil before its first request (thread-safe)
+func (s *SessionContext) Server() *mcp.Server {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.server
+}
+
+// SetClientSession records the MCP session of the client that log messages are forwarded to (thread-safe)
+func (s *SessionContext) SetClientSession(clientSession *mcp.ServerSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clientSession = clientSession
+}
+
+// LogToClient sends a log message to the client of the session. The message is dropped
+// unless the client asked for its level or a lower one with logging/setLevel.
+// Messages sent with the context of a request are delivered alongside its response.
+func (s *SessionContext) LogToClient(ctx context.Context, level mcp.LoggingLevel, logger string, data any) {
+	s.mu.RLock()
+	clientSession := s.clientSession
+	s.mu.RUnlock()
+
+	if clientSession == nil {
+		return
+	}
+
+	params := &mcp.LoggingMessageParams{Level: level, Logger: logger, Data: data}
+	if err := clientSession.Log(ctx, params); err != nil {
+		slog.DebugContext(ctx, "Failed to forward log message to client", logging.SessionKey, s.SessionID, "logger", logger, "error", err)
+	}
+}
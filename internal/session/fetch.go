@@ -0,0 +1,105 @@
+package session
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yousuf/runbyte/internal/config"
+	"github.com/yousuf/runbyte/internal/sandbox"
+)
+
+// fetchAuditLog records outbound requests made with fetch() as JSON lines.
+// Without an audit file, entries are written to the server log.
+type fetchAuditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// newFetchAuditLog opens the audit file for appending, falling back to the server log
+func newFetchAuditLog(path string) *fetchAuditLog {
+	audit := &fetchAuditLog{}
+	if path == "" {
+		return audit
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		slog.Warn("Failed to create fetch audit log directory, using server log", "error", err)
+		return audit
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		slog.Warn("Failed to open fetch audit log, using server log", "path", path, "error", err)
+		return audit
+	}
+
+	audit.file = file
+	return audit
+}
+
+// Record writes an audit entry
+func (a *fetchAuditLog) Record(entry sandbox.FetchAuditEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		slog.Info("Fetch audit", "entry", json.RawMessage(line))
+		return
+	}
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		slog.Warn("Failed to write fetch audit log", "error", err)
+	}
+}
+
+// Close closes the audit file
+func (a *fetchAuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+// initializeFetcher enables fetch() for a session when hosts are allowed in the config
+func (m *Manager) initializeFetcher(session *SessionContext) {
+	cfg := m.config.GetFetch()
+	if len(cfg.Allow) == 0 {
+		return
+	}
+
+	session.Fetcher = sandbox.NewFetcher(fetchPolicy(cfg), func(entry sandbox.FetchAuditEntry) {
+		entry.SessionID = session.SessionID
+		m.fetchAudit.Record(entry)
+	})
+}
+
+// fetchPolicy converts the fetch config to a sandbox fetch policy
+func fetchPolicy(cfg config.FetchConfig) sandbox.FetchPolicy {
+	rules := make([]sandbox.FetchRule, 0, len(cfg.Allow))
+	for _, allow := range cfg.Allow {
+		rules = append(rules, sandbox.FetchRule{
+			Host:    allow.Host,
+			Methods: allow.Methods,
+			Headers: allow.Headers,
+		})
+	}
+
+	return sandbox.FetchPolicy{
+		Rules:           rules,
+		MaxRequestSize:  cfg.MaxRequestSize,
+		MaxResponseSize: cfg.MaxResponseSize,
+		Timeout:         time.Duration(cfg.Timeout) * time.Second,
+	}
+}
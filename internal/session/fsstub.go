@@ -9,11 +9,12 @@ import (
 const fsStubTemplate = `/**
  * Runbyte Sandbox Filesystem API
  * 
- * Available directories:
+ * Default directories (configurable via filesystem.mounts in runbyte.json):
  * - workspace: Persistent user data (read/write)
- * - cache: Temporary cache (read/write, may be cleared)
+ * - cache: Temporary cache (read/write, cleared when the session ends)
  * - temp: Ephemeral data (read/write, cleared after execution)
- * - config: Read-only configuration
+ *
+ * Use list_directory on '/' to see the directories mounted for this session.
  * 
  * @example
  * ` + "```typescript" + `
@@ -21,20 +22,58 @@ const fsStubTemplate = `/**
  * 
  * const data = await fs.readFile('./workspace/config.json');
  * await fs.writeFile('./cache/result.json', JSON.stringify(result));
+ * await fs.appendFile('./workspace/history.log', 'done\n');
  * ` + "```" + `
  */
 
 // @ts-ignore - Injected by WASM runtime
 const ws = globalThis.__runbyte_workspace;
 
+/**
+ * Byte range for partial reads of large files
+ */
+export interface ReadOptions {
+    /** Byte offset to start reading at (default: 0) */
+    offset?: number;
+    /** Maximum number of bytes to read (default: to end of file) */
+    length?: number;
+}
+
+/**
+ * Information about a file or directory
+ */
+export interface FileStat {
+    name: string;
+    /** Size in bytes */
+    size: number;
+    /** Last modification time */
+    mtime: Date;
+    /** Last modification time in milliseconds since the epoch */
+    mtimeMs: number;
+    isDirectory: boolean;
+    isFile: boolean;
+}
+
 /**
  * Read a file from any directory
  * @param path - Path with directory prefix (e.g., './workspace/data.txt')
+ * @param options - Optional byte range to read
  * @returns File contents as string
  * @throws Error if file not found or path is invalid
  */
-export async function readFile(path: string): Promise<string> {
-    return ws.readFile(path);
+export async function readFile(path: string, options?: ReadOptions): Promise<string> {
+    return ws.readFile(path, options);
+}
+
+/**
+ * Read a binary file from any directory
+ * @param path - Path with directory prefix (e.g., './workspace/image.png')
+ * @param options - Optional byte range to read, for processing large files in chunks
+ * @returns File contents as bytes
+ * @throws Error if file not found or path is invalid
+ */
+export async function readBytes(path: string, options?: ReadOptions): Promise<Uint8Array> {
+    return ws.readBytes(path, options);
 }
 
 /**
@@ -47,6 +86,74 @@ export async function writeFile(path: string, content: string): Promise<void> {
     return ws.writeFile(path, content);
 }
 
+/**
+ * Write binary data to a file in a writable directory
+ * @param path - Path with directory prefix (e.g., './workspace/image.png')
+ * @param data - Bytes to write
+ * @throws Error if directory is read-only or limits exceeded
+ */
+export async function writeBytes(path: string, data: Uint8Array | ArrayBuffer): Promise<void> {
+    return ws.writeBytes(path, data);
+}
+
+/**
+ * Append content to a file, creating it if it does not exist
+ * @param path - Path with directory prefix (e.g., './workspace/history.log')
+ * @param content - Text or bytes to append
+ * @throws Error if directory is read-only or limits exceeded
+ */
+export async function appendFile(path: string, content: string | Uint8Array | ArrayBuffer): Promise<void> {
+    return ws.appendFile(path, content);
+}
+
+/**
+ * Get the size, modification time and type of a file or directory
+ * @param path - Path to inspect
+ * @throws Error if the path does not exist
+ */
+export async function stat(path: string): Promise<FileStat> {
+    return ws.stat(path);
+}
+
+/**
+ * Create a directory, including any missing parent directories
+ * @param path - Directory path (e.g., './workspace/reports/2024')
+ * @throws Error if directory is read-only
+ */
+export async function mkdir(path: string): Promise<void> {
+    return ws.mkdir(path);
+}
+
+/**
+ * Rename or move a file or directory, replacing an existing destination file.
+ * Moves between directories (e.g., temp to workspace) are supported for files.
+ * @param from - Current path
+ * @param to - New path
+ * @throws Error if either directory is read-only or destination limits are exceeded
+ */
+export async function rename(from: string, to: string): Promise<void> {
+    return ws.rename(from, to);
+}
+
+/**
+ * Move a file or directory (alias of rename)
+ * @param from - Current path
+ * @param to - New path
+ */
+export async function move(from: string, to: string): Promise<void> {
+    return rename(from, to);
+}
+
+/**
+ * Copy a file, replacing an existing destination file
+ * @param from - Source path
+ * @param to - Destination path in a writable directory
+ * @throws Error if the source is a directory or destination limits are exceeded
+ */
+export async function copy(from: string, to: string): Promise<void> {
+    return ws.copy(from, to);
+}
+
 /**
  * List files and directories at the given path
  * @param path - Directory path (e.g., './workspace' or './workspace/subdir')
@@ -65,13 +172,166 @@ export async function deleteFile(path: string): Promise<void> {
     return ws.deleteFile(path);
 }
 
+/**
+ * Options for glob and grep
+ */
+export interface SearchOptions {
+    /** Directory to search (e.g., './workspace/reports' or '/servers/github'; default: all directories including /servers) */
+    path?: string;
+    /** Maximum directory depth below path (1 searches only path itself; default: unlimited) */
+    maxDepth?: number;
+    /** Maximum number of results (default: 100, max: 1000) */
+    limit?: number;
+}
+
+/**
+ * A file found by glob
+ */
+export interface FileMatch {
+    /** Path of the file (e.g., '/workspace/reports/summary.md') */
+    path: string;
+    /** Size in bytes */
+    size: number;
+    /** Last modification time */
+    mtime: Date;
+    /** Last modification time in milliseconds since the epoch */
+    mtimeMs: number;
+}
+
+/**
+ * Options for grep
+ */
+export interface GrepOptions extends SearchOptions {
+    /** Only search files matching this glob (e.g., '*.ts') */
+    include?: string;
+    /** Match case-insensitively */
+    ignoreCase?: boolean;
+}
+
+/**
+ * A line found by grep
+ */
+export interface GrepMatch {
+    /** Path of the file (e.g., '/servers/github/listRepos.ts') */
+    path: string;
+    /** 1-based line number */
+    line: number;
+    /** The matching line (truncated if very long) */
+    text: string;
+    /** Size of the file in bytes */
+    size: number;
+}
+
+/**
+ * Find files by glob pattern, recursively.
+ * Patterns without '/' match file names at any depth ('*.json'); patterns with '/' match
+ * paths relative to options.path, where '**' matches any number of directories ('reports/**\/*.md').
+ * Besides the mounted directories, the generated server libraries under /servers can be searched.
+ * At most options.limit files are returned.
+ * @param pattern - Glob pattern (default: '*', all files)
+ * @param options - Directory, depth and result limits
+ * @example
+ * ` + "```typescript" + `
+ * const reports = await fs.glob('*.md', { path: './workspace/reports' });
+ * const largest = reports.sort((a, b) => b.size - a.size)[0];
+ * ` + "```" + `
+ */
+export async function glob(pattern?: string, options?: SearchOptions): Promise<FileMatch[]> {
+    return ws.glob(pattern, options);
+}
+
+/**
+ * Find lines matching a regular expression, recursively.
+ * Binary files and files larger than 1MB are skipped. At most options.limit lines are returned.
+ * @param query - Regular expression (Go RE2 syntax) or RegExp
+ * @param options - Directory, file filter, depth and result limits
+ * @example
+ * ` + "```typescript" + `
+ * const hits = await fs.grep('cursor|pageToken', { path: '/servers', include: '*.ts', ignoreCase: true });
+ * ` + "```" + `
+ */
+export async function grep(query: string | RegExp, options?: GrepOptions): Promise<GrepMatch[]> {
+    return ws.grep(query, options);
+}
+
+/**
+ * A previous version of a file
+ */
+export interface FileVersion {
+    /** Version ID to pass to restore */
+    id: string;
+    /** Size in bytes */
+    size: number;
+    /** When this content was replaced or deleted */
+    savedAt: Date;
+}
+
+/**
+ * List previous versions of a file, newest first.
+ * A version is kept each time a file is overwritten, replaced by a rename, or deleted
+ * (appends are not versioned). Deleted files keep their versions, so deletes can be undone.
+ * Old versions are dropped beyond the directory's maxVersions, or when space is needed.
+ * @param path - Path of the file (it may have been deleted)
+ * @returns Versions, newest first (empty if versioning is disabled for the directory)
+ */
+export async function listVersions(path: string): Promise<FileVersion[]> {
+    return ws.listVersions(path);
+}
+
+/**
+ * Restore a previous version of a file.
+ * The current content is kept as a new version, so a restore can itself be undone.
+ * @param path - Path of the file
+ * @param versionId - Version ID from listVersions
+ * @example
+ * ` + "```typescript" + `
+ * const [latest] = await fs.listVersions('./workspace/report.json');
+ * if (latest) await fs.restore('./workspace/report.json', latest.id);
+ * ` + "```" + `
+ */
+export async function restore(path: string, versionId: string): Promise<void> {
+    return ws.restore(path, versionId);
+}
+
+/**
+ * Options for withLock
+ */
+export interface LockOptions {
+    /** Maximum time to wait for the lock in milliseconds (default: 10000, max: 60000) */
+    timeoutMs?: number;
+}
+
+/**
+ * Run a function while holding an advisory lock on a path.
+ * Other executions (in this or other sessions sharing the directory) calling withLock
+ * on the same path wait until the lock is released. Locks are released when fn settles,
+ * or when the execution ends. Writes are atomic even without a lock; use withLock to make
+ * read-modify-write sequences safe.
+ * @param path - Path to lock (the file does not need to exist)
+ * @param fn - Function to run while holding the lock
+ * @param options - Lock options
+ * @returns The result of fn
+ * @throws Error if the lock cannot be acquired within the timeout
+ * @example
+ * ` + "```typescript" + `
+ * await fs.withLock('./workspace/counter.json', async () => {
+ *     const counter = await fs.readJSON('./workspace/counter.json');
+ *     counter.value++;
+ *     await fs.writeJSON('./workspace/counter.json', counter);
+ * });
+ * ` + "```" + `
+ */
+export async function withLock<T>(path: string, fn: () => T | Promise<T>, options?: LockOptions): Promise<T> {
+    return ws.withLock(path, fn, options);
+}
+
 /**
  * Check if a file exists (convenience wrapper)
  * @param path - Path to check
  */
 export async function exists(path: string): Promise<boolean> {
     try {
-        await readFile(path);
+        await stat(path);
         return true;
     } catch {
         return false;
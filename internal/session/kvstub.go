@@ -0,0 +1,122 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const kvStubTemplate = `/**
+ * Runbyte Key-Value Store API
+ *
+ * A small persistent store kept alongside the workspace, shared by every session
+ * that shares the workspace. Values are any JSON-serializable data.
+ * Quotas are configurable via kv in runbyte.json.
+ *
+ * @example
+ * ` + "```typescript" + `
+ * import * as kv from '@runbyte/kv';
+ *
+ * await kv.set('cache:repos', repos, { ttlMs: 5 * 60 * 1000 });
+ * const cached = await kv.get<Repo[]>('cache:repos');
+ * const runs = await kv.increment('stats:runs');
+ * ` + "```" + `
+ */
+
+// @ts-ignore - Injected by WASM runtime
+const store = globalThis.__runbyte_kv;
+
+/**
+ * Options for set and increment
+ */
+export interface SetOptions {
+    /** Time to live in milliseconds; the key expires afterwards (default: never) */
+    ttlMs?: number;
+}
+
+/**
+ * Options for list
+ */
+export interface ListOptions {
+    /** Maximum number of entries to return (default: all) */
+    limit?: number;
+}
+
+/**
+ * A stored key with its value
+ */
+export interface Entry<T = any> {
+    key: string;
+    value: T;
+    /** When the key expires (undefined if it never expires) */
+    expiresAt?: Date;
+}
+
+/**
+ * Get the value stored under a key
+ * @param key - Key to read
+ * @returns The value, or undefined if the key is missing or expired
+ */
+export async function get<T = any>(key: string): Promise<T | undefined> {
+    return store.get(key);
+}
+
+/**
+ * Store a value under a key, replacing any existing value
+ * @param key - Key to write
+ * @param value - JSON-serializable value
+ * @param options - Optional time to live
+ * @throws Error if the value or store exceeds its quota
+ */
+export async function set(key: string, value: any, options?: SetOptions): Promise<void> {
+    return store.set(key, value, options);
+}
+
+/**
+ * Delete a key
+ * @param key - Key to delete
+ * @returns true if the key existed
+ */
+export async function del(key: string): Promise<boolean> {
+    return store.delete(key);
+}
+
+export { del as delete };
+
+/**
+ * List entries whose keys start with a prefix, sorted by key
+ * @param prefix - Key prefix (default: all keys)
+ * @param options - Optional result limit
+ */
+export async function list<T = any>(prefix: string = '', options?: ListOptions): Promise<Entry<T>[]> {
+    return store.list(prefix, options);
+}
+
+/**
+ * Atomically add to a numeric value, treating a missing key as 0.
+ * Safe to use from concurrent executions.
+ * @param key - Key holding a number
+ * @param delta - Amount to add (default: 1)
+ * @param options - Optional time to live (an existing expiry is kept if omitted)
+ * @returns The new value
+ * @throws Error if the existing value is not a number
+ */
+export async function increment(key: string, delta: number = 1, options?: SetOptions): Promise<number> {
+    return store.increment(key, delta, options);
+}
+`
+
+// generateKVStub generates the @runbyte/kv TypeScript module
+func generateKVStub(bundleDir string) error {
+	kvDir := filepath.Join(bundleDir, "builtin", "@runbyte", "kv")
+	if err := os.MkdirAll(kvDir, 0755); err != nil {
+		return fmt.Errorf("failed to create @runbyte/kv directory: %w", err)
+	}
+
+	stubPath := filepath.Join(kvDir, "index.ts")
+	if err := os.WriteFile(stubPath, []byte(kvStubTemplate), 0644); err != nil {
+		return fmt.Errorf("failed to write @runbyte/kv stub: %w", err)
+	}
+
+	return nil
+}
@@ -3,17 +3,21 @@ package session
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/yousuf/runbyte/internal/bundler"
 	"github.com/yousuf/runbyte/internal/client"
 	"github.com/yousuf/runbyte/internal/codegen"
 	"github.com/yousuf/runbyte/internal/config"
+	"github.com/yousuf/runbyte/internal/logging"
 	"github.com/yousuf/runbyte/internal/sandbox"
 	"github.com/yousuf/runbyte/internal/strutil"
+	"github.com/yousuf/runbyte/internal/toolsearch"
 )
 
 // Manager manages session contexts
@@ -21,18 +25,89 @@ type Manager struct {
 	sessions map[string]*SessionContext
 	mu       sync.RWMutex
 	config   *config.Config
+	semantic *toolsearch.SemanticOptions // Shared semantic search settings (nil if disabled)
+
+	// Workspaces mounted by several sessions (shared and per-principal), keyed by root
+	workspaces map[string]*sharedWorkspace
+
+	// Directories of mounts with an explicit root, keyed by absolute root
+	mountDirs map[string]*sandbox.Directory
+
+	// Key-value stores, keyed by workspace root so sessions sharing a workspace share its store
+	kvStores map[string]*sandbox.KVStore
+
+	// Optional callback for files changed through a session's sandbox filesystem
+	onFileChanged func(session *SessionContext, dirName, relPath string)
+
+	// Audit trail of outbound requests made with fetch()
+	fetchAudit *fetchAuditLog
+
+	// Tool result cache shared by all sessions (nil unless the cache is enabled with shared scope)
+	sharedCache *client.ResultCache
 }
 
 // NewManager creates a new session manager
 func NewManager(cfg *config.Config) *Manager {
-	return &Manager{
+	m := &Manager{
 		sessions: make(map[string]*SessionContext),
 		config:   cfg,
+		semantic: newSemanticOptions(cfg),
+
+		workspaces: make(map[string]*sharedWorkspace),
+		mountDirs:  make(map[string]*sandbox.Directory),
+		kvStores:   make(map[string]*sandbox.KVStore),
+		fetchAudit: newFetchAuditLog(cfg.GetFetch().AuditLog),
+	}
+	if cache := cfg.GetCache(); cache.Enabled && cache.Scope == config.CacheScopeShared {
+		m.sharedCache = client.NewResultCache(cache)
+	}
+	return m
+}
+
+// resultCache returns the tool result cache of a new session, or nil if caching is disabled
+func (m *Manager) resultCache() *client.ResultCache {
+	cache := m.config.GetCache()
+	if !cache.Enabled {
+		return nil
+	}
+	if m.sharedCache != nil {
+		return m.sharedCache
+	}
+	return client.NewResultCache(cache)
+}
+
+// newSemanticOptions creates the embedder and vector cache shared by all session tool indexes
+func newSemanticOptions(cfg *config.Config) *toolsearch.SemanticOptions {
+	if !cfg.GetSemanticSearch() {
+		return nil
+	}
+
+	var embedder toolsearch.Embedder = toolsearch.NewHashEmbedder(0)
+	if e := cfg.Search.Embedder; e != nil {
+		timeout := time.Duration(cfg.GetEmbedderTimeout()) * time.Second
+		embedder = toolsearch.NewHTTPEmbedder(e.URL, e.Model, e.Headers, timeout)
+	}
+
+	opts := &toolsearch.SemanticOptions{
+		Embedder: embedder,
+		Weight:   cfg.GetSemanticWeight(),
+	}
+
+	if dir := cfg.GetSearchCacheDir(); dir != "" {
+		cache, err := toolsearch.NewVectorCache(dir)
+		if err != nil {
+			slog.Warn("Tool vector cache disabled", "error", err)
+		} else {
+			opts.Cache = cache
+		}
 	}
+
+	return opts
 }
 
-// GetOrCreateSession gets an existing session or creates a new one
-func (m *Manager) GetOrCreateSession(ctx context.Context, sessionID string) (*SessionContext, error) {
+// GetOrCreateSession gets an existing session or creates a new one.
+// The principal is only used when creating a session, to select its workspace.
+func (m *Manager) GetOrCreateSession(ctx context.Context, sessionID, principal string) (session *SessionContext, err error) {
 	// Try to get existing session
 	m.mu.RLock()
 	session, exists := m.sessions[sessionID]
@@ -51,14 +126,27 @@ func (m *Manager) GetOrCreateSession(ctx context.Context, sessionID string) (*Se
 		return session, nil
 	}
 
+	start := time.Now()
+	defer func() {
+		sessionCreateSeconds.Observe(time.Since(start).Seconds())
+		if err != nil {
+			sessionsCreated.Inc("error")
+		} else {
+			sessionsCreated.Inc("ok")
+		}
+	}()
+
 	// Create new McpClientHub and connect to all servers
 	clientHub := client.NewMcpClientHub()
 	if err := clientHub.Connect(ctx, m.config); err != nil {
 		return nil, fmt.Errorf("failed to connect client hub: %w", err)
 	}
+	if cache := m.resultCache(); cache != nil {
+		clientHub.SetResultCache(cache)
+	}
 
 	// Initialize session context
-	session = NewSessionContext(sessionID, clientHub)
+	session = NewSessionContext(sessionID, principal, clientHub)
 
 	// Setup bundle directory and generate library files
 	if err := m.initializeSessionBundleDir(ctx, session); err != nil {
@@ -76,15 +164,42 @@ func (m *Manager) GetOrCreateSession(ctx context.Context, sessionID string) (*Se
 		return nil, fmt.Errorf("failed to initialize sandbox filesystem: %w", err)
 	}
 
+	// Open the workspace key-value store
+	if err := m.initializeKVStore(session); err != nil {
+		slog.WarnContext(ctx, "Key-value store unavailable", "error", err)
+	}
+
+	// Enable fetch() for allowed hosts
+	m.initializeFetcher(session)
+
+	// Build tool search index
+	if m.semantic != nil {
+		session.ToolIndex.EnableSemantic(*m.semantic)
+	}
+	if err := session.ToolIndex.Build(ctx, clientHub.Tools()); err != nil {
+		slog.WarnContext(ctx, "Semantic tool search unavailable, using keyword search", "error", err)
+	}
+
 	// Setup automatic library regeneration when MCP servers notify of tool changes
+	logger := slog.With(logging.SessionKey, sessionID)
+	clientHub.SetLogger(logger)
 	clientHub.SetToolsRefreshedCallback(func(serverName string) {
-		log.Printf("Session %s: tools changed for server %q, regenerating libraries...", sessionID, serverName)
+		logger.Info("Tools changed, regenerating libraries", "server", serverName)
 
-		if err := regenerateLibForServer(session, serverName); err != nil {
-			log.Printf("Session %s: failed to regenerate libs for %q: %v", sessionID, serverName, err)
+		if err := m.regenerateLibForServer(session, serverName); err != nil {
+			logger.Error("Failed to regenerate libraries", "server", serverName, "error", err)
 		} else {
-			log.Printf("Session %s: successfully regenerated libs for %q", sessionID, serverName)
+			logger.Info("Regenerated libraries", "server", serverName)
+		}
+	})
+
+	// Forward log messages of MCP servers to the session's client, named after the server
+	clientHub.SetLogMessageCallback(func(serverName string, params *mcp.LoggingMessageParams) {
+		loggerName := serverName
+		if params.Logger != "" {
+			loggerName += "/" + params.Logger
 		}
+		session.LogToClient(context.Background(), params.Level, loggerName, params.Data)
 	})
 
 	m.sessions[sessionID] = session
@@ -92,6 +207,85 @@ func (m *Manager) GetOrCreateSession(ctx context.Context, sessionID string) (*Se
 	return session, nil
 }
 
+// ExecutionTimeout returns the deadline for a single execute_code run
+func (m *Manager) ExecutionTimeout() time.Duration {
+	return time.Duration(m.config.GetExecutionTimeout()) * time.Second
+}
+
+// SandboxOptions returns the sandbox settings for a single execute_code run.
+// traceVerbosity overrides the configured trace verbosity unless empty.
+func (m *Manager) SandboxOptions(traceVerbosity string) sandbox.Options {
+	if traceVerbosity == "" {
+		traceVerbosity = m.config.GetTraceVerbosity()
+	}
+
+	return sandbox.Options{
+		MaxConcurrentCalls: m.config.GetMaxConcurrentCalls(),
+		Trace: sandbox.TraceOptions{
+			Verbosity: traceVerbosity,
+			Redact:    m.config.GetTraceRedact(),
+		},
+		ForwardConsole: m.config.GetForwardConsole(),
+	}
+}
+
+// SetFileChangedCallback sets an optional callback to be notified when a file is written,
+// moved or deleted through a sandbox filesystem (e.g., to notify resource subscribers).
+// It is called for every MCP server with a session mounting the changed directory, with
+// that session and its name for the directory. Must be set once, not per MCP server.
+func (m *Manager) SetFileChangedCallback(callback func(session *SessionContext, dirName, relPath string)) {
+	m.mu.Lock()
+	m.onFileChanged = callback
+	m.mu.Unlock()
+}
+
+// fileChanged forwards a filesystem change to the file changed callback. Directories with
+// the same root are shared by sessions with the same workspace, and in HTTP mode every
+// session has its own MCP server, so each server mounting the directory is notified once.
+func (m *Manager) fileChanged(origin *SessionContext, dirName, relPath string) {
+	cfg, ok := origin.SandboxFS.GetDirectoryConfig(dirName)
+	if !ok {
+		return
+	}
+
+	type target struct {
+		session *SessionContext
+		dirName string
+	}
+
+	m.mu.RLock()
+	callback := m.onFileChanged
+	sessions := make([]*SessionContext, 0, len(m.sessions)+1)
+	sessions = append(sessions, origin)
+	for _, session := range m.sessions {
+		if session != origin {
+			sessions = append(sessions, session)
+		}
+	}
+	m.mu.RUnlock()
+
+	if callback == nil {
+		return
+	}
+
+	var targets []target
+	notified := make(map[*mcp.Server]bool)
+	for _, session := range sessions {
+		server := session.Server()
+		if server == nil || notified[server] || session.SandboxFS == nil {
+			continue
+		}
+		if name, ok := session.SandboxFS.DirectoryWithRoot(cfg.Root); ok {
+			notified[server] = true
+			targets = append(targets, target{session: session, dirName: name})
+		}
+	}
+
+	for _, t := range targets {
+		callback(t.session, t.dirName, relPath)
+	}
+}
+
 // GetSession retrieves an existing session
 func (m *Manager) GetSession(sessionID string) *SessionContext {
 	m.mu.RLock()
@@ -117,14 +311,15 @@ func (m *Manager) DeleteSession(sessionID string) error {
 	// Clean up sandbox filesystem
 	if session.SandboxFS != nil {
 		if err := session.SandboxFS.Cleanup(); err != nil {
-			log.Printf("Warning: failed to cleanup sandbox filesystem: %v", err)
+			slog.Warn("Failed to clean up sandbox filesystem", logging.SessionKey, sessionID, "error", err)
 		}
+		m.releaseKVStore(session)
 	}
 
 	// Clean up bundle directory
 	if session.BundleDir != "" {
 		if err := os.RemoveAll(session.BundleDir); err != nil {
-			log.Printf("Warning: failed to clean up bundle dir %s: %v", session.BundleDir, err)
+			slog.Warn("Failed to clean up bundle directory", logging.SessionKey, sessionID, "path", session.BundleDir, "error", err)
 		}
 	}
 
@@ -146,19 +341,24 @@ func (m *Manager) CloseAll() error {
 		// Clean up sandbox filesystem
 		if session.SandboxFS != nil {
 			if err := session.SandboxFS.Cleanup(); err != nil {
-				log.Printf("Warning: failed to cleanup sandbox filesystem for session %s: %v", sessionID, err)
+				slog.Warn("Failed to clean up sandbox filesystem", logging.SessionKey, sessionID, "error", err)
 			}
 		}
 
 		// Clean up bundle directory
 		if session.BundleDir != "" {
 			if err := os.RemoveAll(session.BundleDir); err != nil {
-				log.Printf("Warning: failed to clean up bundle dir %s: %v", session.BundleDir, err)
+				slog.Warn("Failed to clean up bundle directory", logging.SessionKey, sessionID, "path", session.BundleDir, "error", err)
 			}
 		}
 	}
 
 	m.sessions = make(map[string]*SessionContext)
+	m.kvStores = make(map[string]*sandbox.KVStore)
+
+	if err := m.fetchAudit.Close(); err != nil {
+		slog.Warn("Failed to close fetch audit log", "error", err)
+	}
 
 	if len(errs) > 0 {
 		return fmt.Errorf("errors closing sessions: %v", errs)
@@ -184,7 +384,7 @@ func (m *Manager) initializeSessionBundleDir(ctx context.Context, session *Sessi
 
 	// Get all tools from connected MCP servers and generate TypeScript libraries
 	allTools := session.ClientHub.Tools()
-	generator := codegen.NewTypeScriptGenerator()
+	generator := m.newGenerator()
 
 	// Generate and write per-function library files for each server
 	serverNames := make([]string, 0, len(allTools))
@@ -245,6 +445,18 @@ func (m *Manager) initializeSessionBundleDir(ctx context.Context, session *Sessi
 		return fmt.Errorf("failed to generate @runbyte/fs stub: %w", err)
 	}
 
+	// Generate @runbyte/kv stub
+	if err := generateKVStub(bundleDir); err != nil {
+		os.RemoveAll(bundleDir)
+		return fmt.Errorf("failed to generate @runbyte/kv stub: %w", err)
+	}
+
+	// Generate runtime.d.ts for type checking
+	if err := generateRuntimeDeclarations(bundleDir); err != nil {
+		os.RemoveAll(bundleDir)
+		return fmt.Errorf("failed to generate runtime declarations: %w", err)
+	}
+
 	// Update session
 	session.BundleDir = bundleDir
 
@@ -253,7 +465,7 @@ func (m *Manager) initializeSessionBundleDir(ctx context.Context, session *Sessi
 
 // regenerateLibForServer regenerates TypeScript library for a specific server
 // This is called automatically when the MCP server notifies of tool changes
-func regenerateLibForServer(session *SessionContext, serverName string) error {
+func (m *Manager) regenerateLibForServer(session *SessionContext, serverName string) error {
 	session.mu.Lock()
 	defer session.mu.Unlock()
 
@@ -275,7 +487,7 @@ func regenerateLibForServer(session *SessionContext, serverName string) error {
 	}
 
 	// Generate TypeScript files for this server
-	generator := codegen.NewTypeScriptGenerator()
+	generator := m.newGenerator()
 
 	// Generate a file for each tool/function
 	for _, tool := range tools {
@@ -298,32 +510,107 @@ func regenerateLibForServer(session *SessionContext, serverName string) error {
 		return fmt.Errorf("failed to write index.ts: %w", err)
 	}
 
+	// Rebuild tool search index with the refreshed tools
+	if err := session.ToolIndex.Build(context.Background(), session.ClientHub.Tools()); err != nil {
+		slog.Warn("Semantic tool search unavailable, using keyword search", logging.SessionKey, session.SessionID, "error", err)
+	}
+
 	return nil
 }
 
+// newGenerator creates a TypeScript generator configured from the runbyte config
+func (m *Manager) newGenerator() *codegen.TypeScriptGenerator {
+	return codegen.NewTypeScriptGeneratorWithOptions(codegen.GeneratorOptions{
+		Validators: m.config.GetCodegenValidators(),
+	})
+}
+
 // initializeSandboxFileSystem creates and configures the SandboxFileSystem for a session
 func (m *Manager) initializeSandboxFileSystem(session *SessionContext) error {
-	// Get base directory for session (same as bundle dir parent)
-	sessionBaseDir := filepath.Dir(session.BundleDir)
-
-	// Configure multiple directories
-	directories := []sandbox.DirectoryConfig{
-		{
-			Name:         "workspace",
-			Root:         filepath.Join(sessionBaseDir, "workspace"),
-			ReadOnly:     false,
-			MaxFileSize:  10 * 1024 * 1024, // 10MB per file
-			MaxFiles:     1000,
-			MaxTotalSize: 100 * 1024 * 1024, // 100MB total
-		},
+	// Configure directories from the mount table
+	mounts := m.config.GetMounts()
+	directories := make([]*sandbox.Directory, 0, len(mounts))
+	for _, mount := range mounts {
+		// The workspace is placed according to the workspace scope unless mounted from an explicit root
+		if mount.Name == workspaceMountName && mount.Root == "" {
+			dir, err := m.workspaceDirectory(session, mount)
+			if err != nil {
+				return err
+			}
+			directories = append(directories, dir)
+			continue
+		}
+
+		if mount.Root != "" {
+			dir, err := m.mountDirectory(mount)
+			if err != nil {
+				return err
+			}
+			directories = append(directories, dir)
+			continue
+		}
+
+		// Managed mounts live in the session bundle dir so they are never shared
+		root, err := filepath.Abs(filepath.Join(session.BundleDir, "mounts", mount.Name))
+		if err != nil {
+			return fmt.Errorf("failed to resolve root for mount %s: %w", mount.Name, err)
+		}
+
+		dir, err := sandbox.NewDirectory(directoryConfig(mount, root, sandbox.Lifecycle(mount.Lifecycle)))
+		if err != nil {
+			return fmt.Errorf("failed to create sandbox filesystem: %w", err)
+		}
+		directories = append(directories, dir)
 	}
 
 	// Create SandboxFileSystem
-	sfs, err := sandbox.NewSandboxFileSystem(directories)
+	sfs, err := sandbox.NewSandboxFileSystemWithDirectories(directories)
 	if err != nil {
 		return fmt.Errorf("failed to create sandbox filesystem: %w", err)
 	}
 
+	sfs.SetServersDir(filepath.Join(session.BundleDir, "servers"))
+	sfs.SetChangeHandler(func(dirName, relPath string) {
+		m.fileChanged(session, dirName, relPath)
+	})
+
 	session.SandboxFS = sfs
 	return nil
 }
+
+// mountDirectory returns the directory of a mount with an explicit root. It is created
+// once and mounted by every session while any still uses it, so they share quota, stats
+// and locks. Must be called with m.mu held.
+func (m *Manager) mountDirectory(mount config.MountConfig) (*sandbox.Directory, error) {
+	root, err := filepath.Abs(mount.Root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root for mount %s: %w", mount.Name, err)
+	}
+
+	// A directory no session mounts any more may have been removed with its last session
+	if dir, ok := m.mountDirs[root]; ok && dir.Mounted() {
+		return dir, nil
+	}
+
+	dir, err := sandbox.NewDirectory(directoryConfig(mount, root, sandbox.Lifecycle(mount.Lifecycle)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox filesystem: %w", err)
+	}
+
+	m.mountDirs[root] = dir
+	return dir, nil
+}
+
+// directoryConfig converts a configured mount to a sandbox directory config
+func directoryConfig(mount config.MountConfig, root string, lifecycle sandbox.Lifecycle) sandbox.DirectoryConfig {
+	return sandbox.DirectoryConfig{
+		Name:         mount.Name,
+		Root:         root,
+		ReadOnly:     mount.ReadOnly,
+		MaxFileSize:  mount.MaxFileSize,
+		MaxFiles:     mount.MaxFiles,
+		MaxTotalSize: mount.MaxTotalSize,
+		Lifecycle:    lifecycle,
+		MaxVersions:  mount.MaxVersions,
+	}
+}
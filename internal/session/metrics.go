@@ -0,0 +1,48 @@
+package session
+
+import (
+	"github.com/yousuf/runbyte/internal/metrics"
+)
+
+// Session and workspace metrics
+var (
+	sessionsActive       = metrics.NewGauge("runbyte_sessions_active", "Open MCP sessions")
+	sessionsCreated      = metrics.NewCounter("runbyte_sessions_created_total", "Session creations by result (ok or error)", "result")
+	sessionCreateSeconds = metrics.NewHistogram("runbyte_session_create_duration_seconds", "Time to connect to downstream servers and prepare a new session", metrics.DurationBuckets)
+	mountBytes           = metrics.NewGauge("runbyte_mount_bytes", "Bytes stored per sandbox mount across sessions, counting shared directories once", "mount")
+	mountFiles           = metrics.NewGauge("runbyte_mount_files", "Files stored per sandbox mount across sessions, counting shared directories once", "mount")
+)
+
+// UpdateMetrics sets the session and mount gauges. Register it with metrics.OnScrape.
+func (m *Manager) UpdateMetrics() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessionsActive.Set(float64(len(m.sessions)))
+
+	// Sessions may mount the same directory, e.g. a shared workspace
+	seen := make(map[string]bool)
+	totalBytes := make(map[string]int64)
+	fileCounts := make(map[string]int)
+	for _, session := range m.sessions {
+		if session.SandboxFS == nil {
+			continue
+		}
+		for name, stats := range session.SandboxFS.GetStats() {
+			dirCfg, _ := session.SandboxFS.GetDirectoryConfig(name)
+			if seen[dirCfg.Root] {
+				continue
+			}
+			seen[dirCfg.Root] = true
+			totalBytes[name] += stats.TotalBytes
+			fileCounts[name] += stats.FileCount
+		}
+	}
+
+	mountBytes.Reset()
+	mountFiles.Reset()
+	for name := range totalBytes {
+		mountBytes.Set(float64(totalBytes[name]), name)
+		mountFiles.Set(float64(fileCounts[name]), name)
+	}
+}
@@ -0,0 +1,150 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const runtimeDeclarationsTemplate = `/**
+ * Runbyte Sandbox Runtime Declarations
+ *
+ * Ambient declarations for globals injected by the WASM runtime.
+ * Used when type-checking user code against the generated /servers libraries.
+ * This file is auto-generated. Do not edit manually.
+ */
+
+/**
+ * Call an MCP tool on a downstream server (used by generated tool wrappers)
+ * @param serverName - Name of the MCP server
+ * @param toolName - Original name of the tool
+ * @param args - Arguments to pass to the tool
+ * @param options - Call options
+ */
+declare function callTool(serverName: string, toolName: string, args: any, options?: CallOptions): any;
+
+/**
+ * Options accepted by every generated tool function
+ */
+interface CallOptions {
+    /** Set to false to bypass the tool result cache and always call the server */
+    cache?: boolean;
+}
+
+/**
+ * A tool call in a batch. tool is the original MCP tool name, as passed to callTool
+ * in the generated wrapper.
+ */
+interface BatchCall {
+    server: string;
+    tool: string;
+    args?: Record<string, any>;
+    /** Set to false to bypass the tool result cache */
+    cache?: boolean;
+}
+
+interface BatchOptions {
+    /** Calls of the batch running at once (always within execution.maxConcurrentCalls) */
+    concurrency?: number;
+    /** Run identical calls (same server, tool and arguments) once and share the result */
+    dedupe?: boolean;
+}
+
+type BatchResult<T = any> = { ok: true; value: T } | { ok: false; error: string };
+
+/**
+ * Call many MCP tools with a single host call. Resolves once all calls have completed,
+ * with one result per call in order; failed calls do not reject the batch.
+ */
+declare function batch<T = any>(calls: BatchCall[], options?: BatchOptions): Promise<BatchResult<T>[]>;
+
+/**
+ * Run a callback after a delay. Timers wait in real time and count against the execution timeout.
+ * Pending timeouts run before the execution returns.
+ */
+declare function setTimeout<A extends any[]>(callback: (...args: A) => void, delayMs?: number, ...args: A): number;
+
+/**
+ * Run a callback repeatedly. Intervals still active when exec() settles are dropped.
+ */
+declare function setInterval<A extends any[]>(callback: (...args: A) => void, delayMs?: number, ...args: A): number;
+
+declare function clearTimeout(id: number | undefined): void;
+declare function clearInterval(id: number | undefined): void;
+
+/**
+ * Wait for a number of milliseconds, e.g. between polling attempts
+ */
+declare function sleep(ms: number): Promise<void>;
+
+/**
+ * Options for fetch
+ */
+interface FetchInit {
+    /** HTTP method (default: GET) */
+    method?: string;
+    headers?: Record<string, string> | [string, string][];
+    body?: string | Uint8Array | ArrayBuffer;
+}
+
+/**
+ * Response headers with case-insensitive lookup
+ */
+interface FetchHeaders {
+    get(name: string): string | null;
+    has(name: string): boolean;
+    forEach(callback: (value: string, key: string) => void): void;
+    entries(): IterableIterator<[string, string]>;
+    keys(): IterableIterator<string>;
+    [Symbol.iterator](): IterableIterator<[string, string]>;
+}
+
+/**
+ * Response returned by fetch (the body is fully buffered)
+ */
+interface FetchResponse {
+    readonly ok: boolean;
+    readonly status: number;
+    readonly statusText: string;
+    /** Final URL after redirects */
+    readonly url: string;
+    readonly headers: FetchHeaders;
+    text(): Promise<string>;
+    json<T = any>(): Promise<T>;
+    bytes(): Promise<Uint8Array>;
+    arrayBuffer(): Promise<ArrayBuffer>;
+}
+
+/**
+ * Perform an HTTP request. Only hosts and methods allowed in the fetch section of
+ * runbyte.json can be called; credentials configured there are added by the server.
+ * Rejects when the request is not allowed, times out or exceeds size limits.
+ */
+declare function fetch(input: string | { url: string }, init?: FetchInit): Promise<FetchResponse>;
+
+/**
+ * Console output captured by the sandbox
+ */
+declare const console: {
+    log(...data: any[]): void;
+    info(...data: any[]): void;
+    warn(...data: any[]): void;
+    error(...data: any[]): void;
+    debug(...data: any[]): void;
+};
+`
+
+// generateRuntimeDeclarations generates the runtime.d.ts ambient declarations for the sandbox globals
+func generateRuntimeDeclarations(bundleDir string) error {
+	builtinDir := filepath.Join(bundleDir, "builtin")
+	if err := os.MkdirAll(builtinDir, 0755); err != nil {
+		return fmt.Errorf("failed to create builtin directory: %w", err)
+	}
+
+	declPath := filepath.Join(builtinDir, "runtime.d.ts")
+	if err := os.WriteFile(declPath, []byte(runtimeDeclarationsTemplate), 0644); err != nil {
+		return fmt.Errorf("failed to write runtime declarations: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yousuf/runbyte/internal/config"
+	"github.com/yousuf/runbyte/internal/logging"
+	"github.com/yousuf/runbyte/internal/sandbox"
+)
+
+// workspaceMountName is the mount whose location is controlled by the workspace scope
+const workspaceMountName = "workspace"
+
+// maxPrincipalDirLen limits the readable part of a principal workspace directory name
+const maxPrincipalDirLen = 64
+
+// sharedWorkspace is a workspace directory mounted by several sessions
+type sharedWorkspace struct {
+	scope string
+	dir   *sandbox.Directory
+}
+
+// WorkspaceScopeStats summarizes workspace usage for one scope
+type WorkspaceScopeStats struct {
+	Workspaces int   // Number of workspaces in use
+	TotalBytes int64 // Bytes stored across those workspaces
+	FileCount  int   // Files stored across those workspaces
+}
+
+// workspaceDirectory returns the workspace directory for a session according to the workspace scope.
+// Shared and per-principal workspaces are created once and mounted by every matching session,
+// so they share quota and stats. Must be called with m.mu held.
+func (m *Manager) workspaceDirectory(session *SessionContext, mount config.MountConfig) (*sandbox.Directory, error) {
+	baseDir, err := filepath.Abs(m.config.GetWorkspaceBaseDir())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve workspace base dir: %w", err)
+	}
+
+	scope := m.config.GetWorkspaceScope()
+	if scope == config.ScopePrincipal && session.Principal == "" {
+		slog.Warn("No principal identified, using a per-session workspace", logging.SessionKey, session.SessionID)
+		scope = config.ScopeSession
+	}
+	session.WorkspaceScope = scope
+
+	var root string
+	switch scope {
+	case config.ScopeShared:
+		root = filepath.Join(baseDir, "shared")
+	case config.ScopePrincipal:
+		root = filepath.Join(baseDir, "principals", principalDirName(session.Principal))
+	default:
+		root = filepath.Join(baseDir, "sessions", session.SessionID)
+		dir, err := sandbox.NewDirectory(directoryConfig(mount, root, sandbox.LifecycleSession))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create workspace: %w", err)
+		}
+		return dir, nil
+	}
+
+	if ws, ok := m.workspaces[root]; ok {
+		return ws.dir, nil
+	}
+
+	// Shared and per-principal workspaces outlive sessions and restarts
+	dir, err := sandbox.NewDirectory(directoryConfig(mount, root, sandbox.LifecyclePersistent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	m.workspaces[root] = &sharedWorkspace{scope: scope, dir: dir}
+	return dir, nil
+}
+
+// initializeKVStore opens the key-value store kept in the session's workspace mount.
+// Read-only or missing workspaces have no store. Must be called with m.mu held.
+func (m *Manager) initializeKVStore(session *SessionContext) error {
+	cfg, ok := session.SandboxFS.GetDirectoryConfig(workspaceMountName)
+	if !ok || cfg.ReadOnly {
+		return nil
+	}
+
+	if kv, ok := m.kvStores[cfg.Root]; ok {
+		session.KV = kv
+		return nil
+	}
+
+	limits := m.config.GetKV()
+	kv, err := sandbox.NewKVStore(filepath.Join(cfg.Root, sandbox.KVFileName), sandbox.KVLimits{
+		MaxKeys:      limits.MaxKeys,
+		MaxValueSize: limits.MaxValueSize,
+		MaxTotalSize: limits.MaxTotalSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	m.kvStores[cfg.Root] = kv
+	session.KV = kv
+	return nil
+}
+
+// releaseKVStore forgets the store of a session whose workspace is removed with it.
+// Must be called with m.mu held, after the session's filesystem is cleaned up.
+func (m *Manager) releaseKVStore(session *SessionContext) {
+	cfg, ok := session.SandboxFS.GetDirectoryConfig(workspaceMountName)
+	if !ok || cfg.Lifecycle == sandbox.LifecyclePersistent {
+		return
+	}
+	if dir, shared := m.mountDirs[cfg.Root]; shared && dir.Mounted() {
+		return
+	}
+	delete(m.kvStores, cfg.Root)
+}
+
+// WorkspaceStats returns workspace usage aggregated per scope
+func (m *Manager) WorkspaceStats() map[string]WorkspaceScopeStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make(map[string]WorkspaceScopeStats)
+	add := func(scope string, s sandbox.Stats) {
+		scopeStats := stats[scope]
+		scopeStats.Workspaces++
+		scopeStats.TotalBytes += s.TotalBytes
+		scopeStats.FileCount += s.FileCount
+		stats[scope] = scopeStats
+	}
+
+	for _, ws := range m.workspaces {
+		add(ws.scope, ws.dir.GetStats())
+	}
+
+	// Per-session workspaces are only reachable through their session
+	for _, session := range m.sessions {
+		if session.WorkspaceScope != config.ScopeSession || session.SandboxFS == nil {
+			continue
+		}
+		if s, ok := session.SandboxFS.GetStats()[workspaceMountName]; ok {
+			add(config.ScopeSession, s)
+		}
+	}
+
+	return stats
+}
+
+// ResolvePrincipal identifies the principal making a request.
+// Verified bearer token info takes precedence, then the principal header, which is only
+// configured behind a trusted proxy. Requests without HTTP headers come from the local
+// stdio client and are attributed to the OS user.
+func (m *Manager) ResolvePrincipal(extra *mcp.RequestExtra) string {
+	if extra == nil || extra.Header == nil {
+		return localPrincipal()
+	}
+
+	if extra.TokenInfo != nil {
+		if sub, ok := extra.TokenInfo.Extra["sub"].(string); ok && sub != "" {
+			return sub
+		}
+	}
+
+	if header := m.config.GetPrincipalHeader(); header != "" {
+		return strings.TrimSpace(extra.Header.Get(header))
+	}
+
+	return ""
+}
+
+// localPrincipal returns the principal used for the local stdio client
+func localPrincipal() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return "local:" + u.Username
+	}
+	return "local"
+}
+
+// principalDirName converts a principal into a safe, stable directory name.
+// A hash suffix keeps names unique when sanitization maps different principals to the same text.
+func principalDirName(principal string) string {
+	var sb strings.Builder
+	for _, r := range principal {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('_')
+		}
+		if sb.Len() >= maxPrincipalDirLen {
+			break
+		}
+	}
+
+	sum := sha256.Sum256([]byte(principal))
+	return strings.TrimLeft(sb.String(), ".") + "-" + hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,357 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/yousuf/runbyte/internal/config"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// scopeName is the instrumentation scope reported with every span
+const scopeName = "github.com/yousuf/runbyte"
+
+// Export batching
+const (
+	queueSize     = 2048             // Ended spans waiting for export; further spans are dropped
+	batchSize     = 512              // Spans per export
+	flushInterval = 5 * time.Second  // Maximum delay before a span is exported
+	exportTimeout = 10 * time.Second // Per export request
+)
+
+// exporter sends batches of ended spans to their destination
+type exporter interface {
+	export(ctx context.Context, spans []*Span) error
+	close() error
+}
+
+// provider batches ended spans and hands them to the exporter in the background
+type provider struct {
+	exporter exporter
+	queue    chan *Span
+	stop     chan struct{}
+	done     chan struct{}
+	dropped  atomic.Int64
+}
+
+// active is the provider spans are exported to, or nil while tracing is disabled
+var active atomic.Pointer[provider]
+
+func current() *provider {
+	return active.Load()
+}
+
+// Initialize starts exporting spans as configured. Tracing stays disabled without
+// an exporter. In stdio mode the stdout exporter writes to stderr, as stdout
+// carries the MCP protocol.
+func Initialize(cfg config.TelemetryConfig, stdio bool) error {
+	var exp exporter
+	switch cfg.Exporter {
+	case "":
+		return nil
+	case config.ExporterOTLP:
+		exp = newOTLPExporter(cfg)
+	case config.ExporterStdout:
+		out := os.Stdout
+		if stdio {
+			out = os.Stderr
+		}
+		exp = &jsonExporter{w: out, service: cfg.ServiceName}
+	case config.ExporterFile:
+		file, err := os.OpenFile(cfg.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open telemetry file: %w", err)
+		}
+		exp = &jsonExporter{w: file, closer: file, service: cfg.ServiceName}
+	default:
+		return fmt.Errorf("unsupported telemetry exporter: %s", cfg.Exporter)
+	}
+
+	p := &provider{
+		exporter: exp,
+		queue:    make(chan *Span, queueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	active.Store(p)
+	return nil
+}
+
+// Shutdown disables tracing and exports the spans still queued, giving up when ctx is done
+func Shutdown(ctx context.Context) error {
+	p := active.Swap(nil)
+	if p == nil {
+		return nil
+	}
+
+	close(p.stop)
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return fmt.Errorf("telemetry shutdown: %w", ctx.Err())
+	}
+	return p.exporter.close()
+}
+
+// enqueue queues an ended span without blocking the caller
+func (p *provider) enqueue(span *Span) {
+	select {
+	case p.queue <- span:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+// run exports full batches as they fill up and partial ones periodically
+func (p *provider) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, batchSize)
+	flush := func() {
+		if dropped := p.dropped.Swap(0); dropped > 0 {
+			slog.Warn("Dropped spans because the export queue was full", "spans", dropped)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		if err := p.exporter.export(ctx, batch); err != nil {
+			slog.Warn("Failed to export spans", "spans", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span := <-p.queue:
+			batch = append(batch, span)
+			if len(batch) == batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-p.stop:
+			for {
+				select {
+				case span := <-p.queue:
+					batch = append(batch, span)
+					if len(batch) == batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// otlpExporter posts spans as protobuf to an OTLP/HTTP collector
+type otlpExporter struct {
+	url      string
+	headers  map[string]string
+	resource *resourcev1.Resource
+	client   *http.Client
+}
+
+func newOTLPExporter(cfg config.TelemetryConfig) *otlpExporter {
+	url := strings.TrimSuffix(cfg.Endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+
+	return &otlpExporter{
+		url:     url,
+		headers: cfg.Headers,
+		resource: &resourcev1.Resource{
+			Attributes: []*commonv1.KeyValue{protoAttr(Attr{Key: "service.name", Value: cfg.ServiceName})},
+		},
+		client: &http.Client{},
+	}
+}
+
+func (e *otlpExporter) export(ctx context.Context, spans []*Span) error {
+	protoSpans := make([]*tracev1.Span, len(spans))
+	for i, span := range spans {
+		protoSpans[i] = span.proto()
+	}
+
+	body, err := proto.Marshal(&tracev1.TracesData{
+		ResourceSpans: []*tracev1.ResourceSpans{{
+			Resource: e.resource,
+			ScopeSpans: []*tracev1.ScopeSpans{{
+				Scope: &commonv1.InstrumentationScope{Name: scopeName},
+				Spans: protoSpans,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send spans: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector returned %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (e *otlpExporter) close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// proto converts an ended span to its OTLP representation
+func (s *Span) proto() *tracev1.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	span := &tracev1.Span{
+		TraceId:           s.context.traceID[:],
+		SpanId:            s.context.spanID[:],
+		Name:              s.name,
+		Kind:              tracev1.Span_SpanKind(s.kind),
+		StartTimeUnixNano: uint64(s.start.UnixNano()),
+		EndTimeUnixNano:   uint64(s.end.UnixNano()),
+		Status:            &tracev1.Status{Code: tracev1.Status_STATUS_CODE_UNSET},
+	}
+	if s.parentID != [8]byte{} {
+		span.ParentSpanId = s.parentID[:]
+	}
+	if s.failed {
+		span.Status = &tracev1.Status{Code: tracev1.Status_STATUS_CODE_ERROR, Message: s.statusMsg}
+	}
+	for _, attr := range s.attrs {
+		span.Attributes = append(span.Attributes, protoAttr(attr))
+	}
+	return span
+}
+
+// protoAttr converts an attribute, formatting values of unsupported types as strings
+func protoAttr(attr Attr) *commonv1.KeyValue {
+	value := &commonv1.AnyValue{}
+	switch v := attr.Value.(type) {
+	case string:
+		value.Value = &commonv1.AnyValue_StringValue{StringValue: v}
+	case bool:
+		value.Value = &commonv1.AnyValue_BoolValue{BoolValue: v}
+	case int:
+		value.Value = &commonv1.AnyValue_IntValue{IntValue: int64(v)}
+	case int64:
+		value.Value = &commonv1.AnyValue_IntValue{IntValue: v}
+	case float64:
+		value.Value = &commonv1.AnyValue_DoubleValue{DoubleValue: v}
+	default:
+		value.Value = &commonv1.AnyValue_StringValue{StringValue: fmt.Sprint(v)}
+	}
+	return &commonv1.KeyValue{Key: attr.Key, Value: value}
+}
+
+// jsonExporter writes one JSON line per span, for local testing
+type jsonExporter struct {
+	w       io.Writer
+	closer  io.Closer // Set when the exporter owns the writer
+	service string
+}
+
+// jsonSpan is the JSON line written for a span
+type jsonSpan struct {
+	Service       string         `json:"service"`
+	TraceID       string         `json:"traceId"`
+	SpanID        string         `json:"spanId"`
+	ParentSpanID  string         `json:"parentSpanId,omitempty"`
+	Name          string         `json:"name"`
+	Kind          string         `json:"kind"`
+	Start         time.Time      `json:"start"`
+	DurationMs    float64        `json:"durationMs"`
+	Status        string         `json:"status"` // "unset" or "error"
+	StatusMessage string         `json:"statusMessage,omitempty"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+}
+
+// kindNames maps span kinds to their JSON names
+var kindNames = map[Kind]string{KindInternal: "internal", KindServer: "server", KindClient: "client"}
+
+func (e *jsonExporter) export(ctx context.Context, spans []*Span) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, span := range spans {
+		if err := enc.Encode(span.json(e.service)); err != nil {
+			return fmt.Errorf("failed to encode span: %w", err)
+		}
+	}
+
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+func (e *jsonExporter) close() error {
+	if e.closer != nil {
+		return e.closer.Close()
+	}
+	return nil
+}
+
+// json converts an ended span to its JSON line
+func (s *Span) json(service string) jsonSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	span := jsonSpan{
+		Service:    service,
+		TraceID:    s.context.TraceID(),
+		SpanID:     s.context.SpanID(),
+		Name:       s.name,
+		Kind:       kindNames[s.kind],
+		Start:      s.start,
+		DurationMs: float64(s.end.Sub(s.start).Microseconds()) / 1000,
+		Status:     "unset",
+	}
+	if s.parentID != [8]byte{} {
+		span.ParentSpanID = fmt.Sprintf("%x", s.parentID)
+	}
+	if s.failed {
+		span.Status, span.StatusMessage = "error", s.statusMsg
+	}
+	if len(s.attrs) > 0 {
+		span.Attributes = make(map[string]any, len(s.attrs))
+		for _, attr := range s.attrs {
+			span.Attributes[attr.Key] = attr.Value
+		}
+	}
+	return span
+}
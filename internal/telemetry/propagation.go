@@ -0,0 +1,92 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TraceparentKey is the W3C Trace Context header, also used as the MCP _meta key
+const TraceparentKey = "traceparent"
+
+// Traceparent returns the W3C traceparent of the span of ctx, or empty string if there is none
+func Traceparent(ctx context.Context) string {
+	sc, ok := ctx.Value(spanContextKey{}).(spanContext)
+	if !ok {
+		return ""
+	}
+
+	flags := "00"
+	if sc.sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID(), sc.SpanID(), flags)
+}
+
+// WithTraceparent returns a context whose next span continues the trace of a
+// W3C traceparent received from a remote caller. Invalid values are ignored.
+func WithTraceparent(ctx context.Context, traceparent string) context.Context {
+	if current() == nil {
+		return ctx
+	}
+
+	sc, ok := parseTraceparent(traceparent)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// Inject sets the traceparent header of an outgoing request from the span of ctx
+func Inject(ctx context.Context, header http.Header) {
+	if traceparent := Traceparent(ctx); traceparent != "" {
+		header.Set(TraceparentKey, traceparent)
+	}
+}
+
+// Extract continues the trace of the traceparent header of an incoming request
+func Extract(ctx context.Context, header http.Header) context.Context {
+	if header == nil {
+		return ctx
+	}
+	return WithTraceparent(ctx, header.Get(TraceparentKey))
+}
+
+// parseTraceparent parses "version-traceid-spanid-flags", rejecting all-zero IDs
+func parseTraceparent(value string) (spanContext, bool) {
+	var sc spanContext
+
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[3]) != 2 {
+		return sc, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, false
+	}
+	if _, err := hex.DecodeString(parts[0]); err != nil {
+		return sc, false
+	}
+
+	traceID, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceID) != len(sc.traceID) {
+		return sc, false
+	}
+	spanID, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanID) != len(sc.spanID) {
+		return sc, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, false
+	}
+
+	copy(sc.traceID[:], traceID)
+	copy(sc.spanID[:], spanID)
+	if sc.traceID == [16]byte{} || sc.spanID == [8]byte{} {
+		return sc, false
+	}
+	sc.sampled = flags[0]&1 == 1
+	return sc, true
+}
@@ -0,0 +1,134 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Kind describes the relationship of a span to its remote peers
+type Kind int
+
+// Span kinds, numbered as in OTLP
+const (
+	KindInternal Kind = 1 // Work inside runbyte
+	KindServer   Kind = 2 // Handling of a request from an MCP client
+	KindClient   Kind = 3 // Request to a downstream MCP server
+)
+
+// spanContext identifies a span across process boundaries
+type spanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+	sampled bool
+}
+
+// TraceID returns the hex-encoded trace ID
+func (sc spanContext) TraceID() string { return hex.EncodeToString(sc.traceID[:]) }
+
+// SpanID returns the hex-encoded span ID
+func (sc spanContext) SpanID() string { return hex.EncodeToString(sc.spanID[:]) }
+
+// Attr is a span attribute. Values are strings, bools, ints, int64s or float64s.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// Span records a timed operation. A nil span is valid and records nothing,
+// so callers need not check whether tracing is enabled.
+type Span struct {
+	name     string
+	kind     Kind
+	context  spanContext
+	parentID [8]byte // Zero for root spans
+	start    time.Time
+
+	mu        sync.Mutex
+	end       time.Time
+	attrs     []Attr
+	failed    bool
+	statusMsg string
+	ended     bool
+}
+
+type spanContextKey struct{}
+
+// Start begins a span as a child of the span of ctx, or of the remote parent
+// extracted into ctx. It returns a context carrying the new span. When tracing
+// is disabled, ctx is returned unchanged with a nil span.
+func Start(ctx context.Context, name string, kind Kind, attrs ...Attr) (context.Context, *Span) {
+	if current() == nil {
+		return ctx, nil
+	}
+
+	span := &Span{name: name, kind: kind, start: time.Now(), attrs: attrs}
+	if parent, ok := ctx.Value(spanContextKey{}).(spanContext); ok {
+		span.context.traceID = parent.traceID
+		span.context.sampled = parent.sampled
+		span.parentID = parent.spanID
+	} else {
+		rand.Read(span.context.traceID[:])
+		span.context.sampled = true
+	}
+	rand.Read(span.context.spanID[:])
+
+	return context.WithValue(ctx, spanContextKey{}, span.context), span
+}
+
+// SetAttr sets an attribute, replacing an earlier value of the same key
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.attrs {
+		if s.attrs[i].Key == key {
+			s.attrs[i].Value = value
+			return
+		}
+	}
+	s.attrs = append(s.attrs, Attr{Key: key, Value: value})
+}
+
+// Fail marks the span as failed with an error message
+func (s *Span) Fail(message string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.failed, s.statusMsg = true, message
+	s.mu.Unlock()
+}
+
+// RecordError marks the span as failed if err is not nil
+func (s *Span) RecordError(err error) {
+	if err != nil {
+		s.Fail(err.Error())
+	}
+}
+
+// End completes the span and queues it for export. Later calls have no effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended, s.end = true, time.Now()
+	s.mu.Unlock()
+
+	if p := current(); p != nil && s.context.sampled {
+		p.enqueue(s)
+	}
+}
@@ -0,0 +1,82 @@
+package toolsearch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// VectorCache stores tool embeddings on disk, keyed by embedder ID and a hash of the tool schema.
+// Vectors are recomputed only when a tool's definition or the embedder changes.
+type VectorCache struct {
+	dir string
+}
+
+// NewVectorCache creates a disk cache rooted at dir
+func NewVectorCache(dir string) (*VectorCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create vector cache dir: %w", err)
+	}
+	return &VectorCache{dir: dir}, nil
+}
+
+// Get returns the cached vector for key, if present
+func (c *VectorCache) Get(key string) ([]float32, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var vec []float32
+	if err := json.Unmarshal(data, &vec); err != nil {
+		return nil, false
+	}
+	return vec, true
+}
+
+// Put stores a vector for key, writing to a temp file first so readers never see partial data
+func (c *VectorCache) Put(key string, vec []float32) error {
+	data, err := json.Marshal(vec)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "vec-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// path returns the cache file path for a key
+func (c *VectorCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// cacheKey returns the cache key for a tool's embedding under the given embedder
+func cacheKey(embedderID, serverName string, tool *mcp.Tool) string {
+	toolJSON, _ := json.Marshal(tool)
+
+	h := sha256.New()
+	h.Write([]byte(embedderID))
+	h.Write([]byte{0})
+	h.Write([]byte(serverName))
+	h.Write([]byte{0})
+	h.Write(toolJSON)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,207 @@
+package toolsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"time"
+)
+
+// Embedder converts texts into vectors for semantic search
+type Embedder interface {
+	// ID identifies the embedder and its model; vectors from different IDs are never mixed
+	ID() string
+	// Embed returns one vector per input text
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// HashEmbedder is a local, model-free embedder that hashes word unigrams, word bigrams
+// and character trigrams into a fixed-size vector (the "hashing trick").
+// It captures lexical overlap, including partial words, without any external dependency.
+type HashEmbedder struct {
+	dim int
+}
+
+// defaultHashDimensions is the vector size used by NewHashEmbedder when dim <= 0
+const defaultHashDimensions = 512
+
+// Feature weights for the hashed n-gram embedder
+const (
+	unigramWeight = 1.0
+	bigramWeight  = 0.7
+	trigramWeight = 0.4
+)
+
+// NewHashEmbedder creates a hashed n-gram embedder with the given vector size
+func NewHashEmbedder(dim int) *HashEmbedder {
+	if dim <= 0 {
+		dim = defaultHashDimensions
+	}
+	return &HashEmbedder{dim: dim}
+}
+
+// ID implements Embedder
+func (e *HashEmbedder) ID() string {
+	return fmt.Sprintf("hash-ngram-v1-%d", e.dim)
+}
+
+// Embed implements Embedder
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+// embed computes the normalized hashed feature vector for a single text
+func (e *HashEmbedder) embed(text string) []float32 {
+	vec := make([]float32, e.dim)
+	terms := tokenize(text)
+
+	for i, term := range terms {
+		e.addFeature(vec, "w:"+term, unigramWeight)
+		if i > 0 {
+			e.addFeature(vec, "b:"+terms[i-1]+" "+term, bigramWeight)
+		}
+
+		padded := []rune("^" + term + "$")
+		for j := 0; j+3 <= len(padded); j++ {
+			e.addFeature(vec, "c:"+string(padded[j:j+3]), trigramWeight)
+		}
+	}
+
+	normalize(vec)
+	return vec
+}
+
+// addFeature hashes a feature into the vector, using a hash bit to pick the sign
+// so that collisions tend to cancel out rather than accumulate
+func (e *HashEmbedder) addFeature(vec []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+
+	idx := int(sum % uint64(e.dim))
+	if sum&(1<<63) != 0 {
+		weight = -weight
+	}
+	vec[idx] += weight
+}
+
+// HTTPEmbedder calls an OpenAI-compatible embeddings endpoint
+// (POST {"model": ..., "input": [...]} -> {"data": [{"embedding": [...]}]})
+type HTTPEmbedder struct {
+	url     string
+	model   string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewHTTPEmbedder creates an embedder backed by an external embeddings endpoint
+func NewHTTPEmbedder(url, model string, headers map[string]string, timeout time.Duration) *HTTPEmbedder {
+	return &HTTPEmbedder{
+		url:     url,
+		model:   model,
+		headers: headers,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// ID implements Embedder
+func (e *HTTPEmbedder) ID() string {
+	return fmt.Sprintf("http:%s:%s", e.url, e.model)
+}
+
+// embeddingRequest is the request body for an OpenAI-compatible embeddings endpoint
+type embeddingRequest struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+// embeddingResponse is the response body of an OpenAI-compatible embeddings endpoint
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed implements Embedder
+func (e *HTTPEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(embeddingRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("embedding endpoint returned %s: %s", resp.Status, msg)
+	}
+
+	var result embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse embedding response: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding endpoint returned %d vectors for %d inputs", len(result.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, item := range result.Data {
+		idx := item.Index
+		if idx < 0 || idx >= len(vectors) {
+			idx = i
+		}
+		normalize(item.Embedding)
+		vectors[idx] = item.Embedding
+	}
+	return vectors, nil
+}
+
+// normalize scales a vector to unit length in place
+func normalize(vec []float32) {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vec {
+		vec[i] /= norm
+	}
+}
+
+// cosine returns the cosine similarity of two unit vectors
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
@@ -0,0 +1,467 @@
+package toolsearch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yousuf/runbyte/internal/codegen"
+	"github.com/yousuf/runbyte/internal/strutil"
+)
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Field weights applied as term frequency multipliers
+const (
+	weightName         = 3
+	weightPropertyName = 2
+	weightDescription  = 1
+)
+
+// Fuzzy match score multipliers relative to an exact term match
+const (
+	prefixMatchFactor = 0.7
+	fuzzyMatchFactor  = 0.5
+)
+
+// minSemanticScore is the cosine similarity below which tools without any
+// keyword match are not considered semantic matches
+const minSemanticScore = 0.15
+
+// SearchMode selects how search results are ranked
+type SearchMode string
+
+const (
+	ModeKeyword  SearchMode = "keyword"  // BM25 with fuzzy term matching
+	ModeSemantic SearchMode = "semantic" // Embedding similarity only
+	ModeHybrid   SearchMode = "hybrid"   // Weighted combination of keyword and semantic scores
+)
+
+// SearchOptions configures a search
+type SearchOptions struct {
+	Query  string
+	Server string // Only return tools from this server if non-empty
+	Limit  int    // Maximum number of results (0 for no limit)
+	Mode   SearchMode
+}
+
+// SemanticOptions enables semantic search on an index
+type SemanticOptions struct {
+	Embedder Embedder
+	Cache    *VectorCache // Optional on-disk cache of tool vectors
+	Weight   float64      // Weight of the semantic score in hybrid mode (0-1)
+}
+
+// Document represents an indexed tool
+type Document struct {
+	ServerName  string
+	ToolName    string
+	FuncName    string   // Generated TypeScript function name (camelCase)
+	Title       string   // Human-readable tool title
+	Description string   // Tool description
+	Signature   string   // TypeScript signature snippet
+	Annotations []string // Behaviour hints (readOnly, destructive, idempotent, openWorld)
+}
+
+// Path returns the virtual filesystem path of the tool's generated file
+func (d *Document) Path() string {
+	return fmt.Sprintf("/servers/%s/%s.ts", d.ServerName, d.FuncName)
+}
+
+// Result represents a ranked search match
+type Result struct {
+	*Document
+	Score    float64 // Final ranking score
+	Keyword  float64 // BM25 score (0 if not matched by keywords)
+	Semantic float64 // Cosine similarity (0 if semantic search is not used)
+}
+
+// Index is a per-session full-text index over tool names, descriptions and input properties.
+// Ranking uses BM25 with field weighting, plus prefix and edit-distance matching so that
+// misspelled or partial query terms still find tools. When semantic search is enabled,
+// tools are also embedded so that intent queries can match tools sharing few keywords.
+type Index struct {
+	docs     []*Document
+	postings map[string]map[int]int // term -> doc index -> weighted term frequency
+	docLen   []int                  // weighted token count per document
+	avgLen   float64
+	vectors  [][]float32 // Per-document embeddings (nil if semantic search is unavailable)
+	semantic *SemanticOptions
+	mu       sync.RWMutex
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{
+		postings: make(map[string]map[int]int),
+	}
+}
+
+// EnableSemantic enables semantic and hybrid search modes.
+// Takes effect on the next Build.
+func (idx *Index) EnableSemantic(opts SemanticOptions) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.semantic = &opts
+}
+
+// SemanticEnabled reports whether tool vectors are available for semantic search
+func (idx *Index) SemanticEnabled() bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.vectors != nil
+}
+
+// Build replaces the index contents with the given tools, grouped by server name.
+// If semantic search is enabled and embedding fails, the index stays keyword-only
+// and the error is returned.
+func (idx *Index) Build(ctx context.Context, tools map[string][]*mcp.Tool) error {
+	generator := codegen.NewTypeScriptGenerator()
+
+	idx.mu.RLock()
+	semantic := idx.semantic
+	idx.mu.RUnlock()
+
+	var embedTools []*mcp.Tool
+	docs := make([]*Document, 0)
+	postings := make(map[string]map[int]int)
+	docLen := make([]int, 0)
+	totalLen := 0
+
+	// Sort server names for deterministic document order
+	serverNames := make([]string, 0, len(tools))
+	for name := range tools {
+		serverNames = append(serverNames, name)
+	}
+	sort.Strings(serverNames)
+
+	for _, serverName := range serverNames {
+		for _, tool := range tools[serverName] {
+			docID := len(docs)
+			docs = append(docs, &Document{
+				ServerName:  serverName,
+				ToolName:    tool.Name,
+				FuncName:    strutil.ToCamelCase(tool.Name),
+				Title:       codegen.ToolTitle(tool),
+				Description: tool.Description,
+				Signature:   generator.GenerateSignature(tool),
+				Annotations: codegen.ToolAnnotationLabels(tool),
+			})
+
+			length := 0
+			for term, tf := range weightedTerms(serverName, tool) {
+				if postings[term] == nil {
+					postings[term] = make(map[int]int)
+				}
+				postings[term][docID] = tf
+				length += tf
+			}
+			docLen = append(docLen, length)
+			totalLen += length
+			embedTools = append(embedTools, tool)
+		}
+	}
+
+	var vectors [][]float32
+	var embedErr error
+	if semantic != nil {
+		vectors, embedErr = embedDocuments(ctx, semantic, docs, embedTools)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.docs = docs
+	idx.postings = postings
+	idx.docLen = docLen
+	idx.vectors = vectors
+	idx.avgLen = 0
+	if len(docs) > 0 {
+		idx.avgLen = float64(totalLen) / float64(len(docs))
+	}
+
+	return embedErr
+}
+
+// embedDocuments returns a vector per document, using cached vectors where the tool is unchanged
+func embedDocuments(ctx context.Context, semantic *SemanticOptions, docs []*Document, tools []*mcp.Tool) ([][]float32, error) {
+	vectors := make([][]float32, len(docs))
+	keys := make([]string, len(docs))
+
+	var missing []int
+	for i, doc := range docs {
+		keys[i] = cacheKey(semantic.Embedder.ID(), doc.ServerName, tools[i])
+		if semantic.Cache != nil {
+			if vec, ok := semantic.Cache.Get(keys[i]); ok {
+				vectors[i] = vec
+				continue
+			}
+		}
+		missing = append(missing, i)
+	}
+
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	texts := make([]string, len(missing))
+	for j, i := range missing {
+		texts[j] = embeddingText(docs[i].ServerName, tools[i])
+	}
+
+	embedded, err := semantic.Embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed tools with %s: %w", semantic.Embedder.ID(), err)
+	}
+
+	for j, i := range missing {
+		vectors[i] = embedded[j]
+		if semantic.Cache != nil {
+			// Cache failures only cost a recomputation next time
+			_ = semantic.Cache.Put(keys[i], embedded[j])
+		}
+	}
+
+	return vectors, nil
+}
+
+// embeddingText builds the text embedded for a tool from the same fields the keyword index uses
+func embeddingText(serverName string, tool *mcp.Tool) string {
+	parts := []string{serverName, strings.Join(tokenize(tool.Name), " ")}
+	if title := codegen.ToolTitle(tool); title != "" {
+		parts = append(parts, title)
+	}
+	if tool.Description != "" {
+		parts = append(parts, tool.Description)
+	}
+
+	if schema, ok := tool.InputSchema.(map[string]interface{}); ok {
+		if properties, ok := schema["properties"].(map[string]interface{}); ok {
+			for _, name := range sortedPropertyNames(properties) {
+				part := strings.Join(tokenize(name), " ")
+				if propMap, ok := properties[name].(map[string]interface{}); ok {
+					if desc, ok := propMap["description"].(string); ok {
+						part += ": " + desc
+					}
+				}
+				parts = append(parts, part)
+			}
+		}
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// sortedPropertyNames returns schema property names in sorted order
+func sortedPropertyNames(properties map[string]interface{}) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Len returns the number of indexed tools
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Search returns tools ranked by relevance to the query.
+// Semantic and hybrid modes fall back to keyword ranking when no tool vectors are available.
+func (idx *Index) Search(ctx context.Context, opts SearchOptions) ([]Result, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	mode := opts.Mode
+	if mode == "" {
+		mode = ModeKeyword
+	}
+	if mode != ModeKeyword && idx.vectors == nil {
+		mode = ModeKeyword
+	}
+
+	keywordScores := make(map[int]float64)
+	if mode != ModeSemantic {
+		for _, term := range uniqueTerms(tokenize(opts.Query)) {
+			for indexTerm, factor := range idx.matchingTerms(term) {
+				idx.scoreTerm(indexTerm, factor, keywordScores)
+			}
+		}
+	}
+
+	var semanticScores map[int]float64
+	if mode != ModeKeyword {
+		var err error
+		semanticScores, err = idx.semanticScores(ctx, opts.Query)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]Result, 0)
+	for docID, doc := range idx.docs {
+		if opts.Server != "" && doc.ServerName != opts.Server {
+			continue
+		}
+
+		keyword, hasKeyword := keywordScores[docID]
+		semantic := semanticScores[docID]
+		if !hasKeyword && semantic < minSemanticScore {
+			continue
+		}
+
+		results = append(results, Result{Document: doc, Keyword: keyword, Semantic: semantic})
+	}
+
+	idx.rank(results, mode)
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path() < results[j].Path()
+	})
+
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results, nil
+}
+
+// semanticScores embeds the query and returns its cosine similarity to every document
+func (idx *Index) semanticScores(ctx context.Context, query string) (map[int]float64, error) {
+	embedded, err := idx.semantic.Embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	scores := make(map[int]float64, len(idx.vectors))
+	for docID, vec := range idx.vectors {
+		scores[docID] = cosine(embedded[0], vec)
+	}
+	return scores, nil
+}
+
+// rank sets the final score of each result for the search mode.
+// Hybrid mode normalizes BM25 scores to 0-1 so they are comparable with cosine similarity.
+func (idx *Index) rank(results []Result, mode SearchMode) {
+	maxKeyword := 0.0
+	for _, r := range results {
+		maxKeyword = math.Max(maxKeyword, r.Keyword)
+	}
+
+	weight := 0.0
+	if idx.semantic != nil {
+		weight = idx.semantic.Weight
+	}
+
+	for i := range results {
+		switch mode {
+		case ModeSemantic:
+			results[i].Score = results[i].Semantic
+		case ModeHybrid:
+			keyword := 0.0
+			if maxKeyword > 0 {
+				keyword = results[i].Keyword / maxKeyword
+			}
+			results[i].Score = weight*math.Max(results[i].Semantic, 0) + (1-weight)*keyword
+		default:
+			results[i].Score = results[i].Keyword
+		}
+	}
+}
+
+// matchingTerms returns index terms matching a query term with their score multipliers:
+// the exact term, terms it is a prefix of, and terms within a small edit distance
+func (idx *Index) matchingTerms(term string) map[string]float64 {
+	matches := make(map[string]float64)
+	if _, ok := idx.postings[term]; ok {
+		matches[term] = 1
+	}
+
+	maxDistance := 0
+	switch {
+	case len(term) >= 8:
+		maxDistance = 2
+	case len(term) >= 4:
+		maxDistance = 1
+	}
+
+	for indexTerm := range idx.postings {
+		if indexTerm == term {
+			continue
+		}
+		if len(term) >= 3 && strings.HasPrefix(indexTerm, term) {
+			matches[indexTerm] = math.Max(matches[indexTerm], prefixMatchFactor)
+			continue
+		}
+		if maxDistance > 0 && abs(len(indexTerm)-len(term)) <= maxDistance &&
+			levenshtein(term, indexTerm) <= maxDistance {
+			matches[indexTerm] = math.Max(matches[indexTerm], fuzzyMatchFactor)
+		}
+	}
+
+	return matches
+}
+
+// scoreTerm adds the BM25 contribution of an index term to each matching document
+func (idx *Index) scoreTerm(term string, factor float64, scores map[int]float64) {
+	docs := idx.postings[term]
+	n := float64(len(idx.docs))
+	df := float64(len(docs))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+	for docID, tf := range docs {
+		freq := float64(tf)
+		norm := 1 - bm25B + bm25B*float64(idx.docLen[docID])/idx.avgLen
+		scores[docID] += factor * idf * (freq * (bm25K1 + 1)) / (freq + bm25K1*norm)
+	}
+}
+
+// weightedTerms returns the weighted term frequencies for a tool across all indexed fields
+func weightedTerms(serverName string, tool *mcp.Tool) map[string]int {
+	terms := make(map[string]int)
+	add := func(text string, weight int) {
+		for _, term := range tokenize(text) {
+			terms[term] += weight
+		}
+	}
+
+	add(tool.Name, weightName)
+	add(serverName, weightDescription)
+	add(codegen.ToolTitle(tool), weightName)
+	add(tool.Description, weightDescription)
+
+	if schema, ok := tool.InputSchema.(map[string]interface{}); ok {
+		if properties, ok := schema["properties"].(map[string]interface{}); ok {
+			for name, propSchema := range properties {
+				add(name, weightPropertyName)
+				if propMap, ok := propSchema.(map[string]interface{}); ok {
+					if desc, ok := propMap["description"].(string); ok {
+						add(desc, weightDescription)
+					}
+				}
+			}
+		}
+	}
+
+	return terms
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,90 @@
+package toolsearch
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are common English words excluded from the index
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "in": true, "is": true, "it": true, "of": true,
+	"on": true, "or": true, "that": true, "the": true, "this": true, "to": true, "with": true,
+}
+
+// tokenize splits text into lowercase terms.
+// Identifiers are split on case changes, underscores and dashes (listRepos -> list, repos).
+func tokenize(text string) []string {
+	var terms []string
+	var current []rune
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		term := strings.ToLower(string(current))
+		current = current[:0]
+		if len(term) > 1 && !stopWords[term] {
+			terms = append(terms, term)
+		}
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+
+		// Split camelCase and PascalCase boundaries (getHTTPResponse -> get, http, response)
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				flush()
+			}
+		}
+
+		current = append(current, r)
+	}
+	flush()
+
+	return terms
+}
+
+// uniqueTerms removes duplicate terms while preserving order
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,231 @@
+package typecheck
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	globalChecker *Checker
+	tscInitOnce   sync.Once
+	tscInitError  error
+)
+
+// UserFileName is the name of the file holding user code in the type-check work directory
+const UserFileName = "index.ts"
+
+// tsconfigTemplate configures tsc to check user code against the generated libraries.
+// Settings mirror the rspack/SWC setup so code that type-checks also bundles.
+const tsconfigTemplate = `{
+  "compilerOptions": {
+    "target": "es2020",
+    "module": "es2020",
+    "moduleResolution": "node",
+    "lib": ["es2020"],
+    "types": [],
+    "noEmit": true,
+    "strict": false,
+    "noImplicitAny": false,
+    "skipLibCheck": true,
+    "isolatedModules": false,
+    "baseUrl": ".",
+    "paths": {
+      "@runbyte/fs": ["./builtin/@runbyte/fs/index.ts"],
+      "@runbyte/kv": ["./builtin/@runbyte/kv/index.ts"]
+    }
+  },
+  "files": ["index.ts", "builtin/runtime.d.ts"]
+}
+`
+
+// diagnosticPattern matches tsc diagnostics printed with --pretty false
+// Example: index.ts(12,5): error TS2345: Argument of type 'string' is not assignable...
+var diagnosticPattern = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\): (error|warning) (TS\d+): (.*)$`)
+
+// Diagnostic represents a single type-check diagnostic in user code
+type Diagnostic struct {
+	Line     int    // 1-indexed line in user code
+	Column   int    // 1-indexed column in user code
+	Severity string // "error" or "warning"
+	Code     string // TypeScript diagnostic code (e.g., "TS2345")
+	Message  string
+}
+
+// String formats the diagnostic like a mapped stack frame location
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d - %s %s: %s", UserFileName, d.Line, d.Column, d.Severity, d.Code, d.Message)
+}
+
+// Checker type-checks user code using the TypeScript compiler
+type Checker struct {
+	tscPath string
+}
+
+// Initialize finds the tsc executable and creates the shared checker
+// Should be called once at application startup when type checking is enabled
+func Initialize() error {
+	tscInitOnce.Do(func() {
+		tscPath, err := findTsc()
+		if err != nil {
+			tscInitError = err
+			return
+		}
+		globalChecker = &Checker{tscPath: tscPath}
+	})
+	return tscInitError
+}
+
+// Default returns the checker created by Initialize, or nil if no TypeScript compiler was found
+func Default() *Checker {
+	return globalChecker
+}
+
+// npxProbeTimeout bounds the check that npx can run an installed TypeScript compiler
+const npxProbeTimeout = 10 * time.Second
+
+// findTsc attempts to locate the TypeScript compiler
+func findTsc() (string, error) {
+	if path, err := exec.LookPath("tsc"); err == nil {
+		return path, nil
+	}
+
+	// Fall back to a typescript package npx finds installed, never downloading one
+	if _, err := exec.LookPath("npx"); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), npxProbeTimeout)
+		defer cancel()
+		if err := exec.CommandContext(ctx, "npx", "--offline", "--no", "--", "tsc", "--version").Run(); err == nil {
+			return "npx", nil
+		}
+	}
+
+	return "", fmt.Errorf("tsc executable not found")
+}
+
+// Check type-checks user code against the session's generated libraries.
+// Only diagnostics located in user code are returned. A non-nil error means the
+// checker itself could not run, in which case callers should skip type checking.
+// tsc is killed when ctx is done.
+func (c *Checker) Check(ctx context.Context, sessionBundleDir, code string) ([]Diagnostic, error) {
+	workID, err := generateWorkID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate work ID: %w", err)
+	}
+
+	workDir := filepath.Join(sessionBundleDir, "typecheck", workID)
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create work dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	// Symlink to shared servers and builtin directories
+	for _, name := range []string{"servers", "builtin"} {
+		if err := os.Symlink(filepath.Join(sessionBundleDir, name), filepath.Join(workDir, name)); err != nil {
+			return nil, fmt.Errorf("failed to create %s symlink: %w", name, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(workDir, UserFileName), []byte(code), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write user code: %w", err)
+	}
+
+	configPath := filepath.Join(workDir, "tsconfig.json")
+	if err := os.WriteFile(configPath, []byte(tsconfigTemplate), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write tsconfig: %w", err)
+	}
+
+	// Execute tsc
+	var cmd *exec.Cmd
+	if c.tscPath == "npx" {
+		cmd = exec.CommandContext(ctx, "npx", "--offline", "--no", "--", "tsc", "-p", configPath, "--pretty", "false")
+	} else {
+		cmd = exec.CommandContext(ctx, c.tscPath, "-p", configPath, "--pretty", "false")
+	}
+
+	var stdout bytes.Buffer
+	cmd.Dir = workDir
+	cmd.Stdout = &stdout
+
+	runErr := cmd.Run()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("tsc stopped: %w", ctxErr)
+	}
+	diagnostics := parseDiagnostics(stdout.String())
+
+	// tsc exits non-zero when it reports diagnostics; anything else is a checker failure
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) || !strings.Contains(stdout.String(), "error TS") {
+			return nil, fmt.Errorf("tsc failed: %w\nOutput: %s", runErr, stdout.String())
+		}
+	}
+
+	return diagnostics, nil
+}
+
+// parseDiagnostics extracts user code diagnostics from tsc output
+// Multi-line messages are folded into the preceding diagnostic.
+func parseDiagnostics(output string) []Diagnostic {
+	var diagnostics []Diagnostic
+	inUserFile := false
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		matches := diagnosticPattern.FindStringSubmatch(line)
+		if matches == nil {
+			// Continuation of the previous message (e.g., elaboration of a type mismatch)
+			if inUserFile && strings.TrimSpace(line) != "" {
+				last := &diagnostics[len(diagnostics)-1]
+				last.Message += "\n  " + strings.TrimSpace(line)
+			}
+			continue
+		}
+
+		inUserFile = matches[1] == UserFileName
+		if !inUserFile {
+			continue
+		}
+
+		lineNum, _ := strconv.Atoi(matches[2])
+		colNum, _ := strconv.Atoi(matches[3])
+		diagnostics = append(diagnostics, Diagnostic{
+			Line:     lineNum,
+			Column:   colNum,
+			Severity: matches[4],
+			Code:     matches[5],
+			Message:  matches[6],
+		})
+	}
+
+	return diagnostics
+}
+
+// FormatDiagnostics formats diagnostics for the execute_code error response
+func FormatDiagnostics(diagnostics []Diagnostic) string {
+	lines := make([]string, len(diagnostics))
+	for i, d := range diagnostics {
+		lines[i] = d.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// generateWorkID creates a unique identifier for a work directory
+func generateWorkID() (string, error) {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
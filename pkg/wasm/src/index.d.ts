@@ -13,26 +13,68 @@ declare module "main" {
 declare module "extism:host" {
     interface user {
         /**
-         * Call an MCP tool on a downstream server
-         * @param ptr Pointer to JSON string containing {serverName, toolName, args}
-         * @returns Pointer to JSON string containing {result, error}
+         * Submit a call to an MCP tool on a downstream server; the call runs in the background
+         * @param ptr Pointer to JSON string containing {serverName, toolName, args, cache}
+         * @returns Pointer to JSON string containing {handle, error}
          */
         callMcpTool(ptr: I64): I64;
 
+        /**
+         * Submit a batch of MCP tool calls; the batch completes once all its calls have
+         * @param ptr Pointer to JSON string containing {calls: [{serverName, toolName, args, cache}], concurrency, dedupe}
+         * @returns Pointer to JSON string containing {handle, error}
+         */
+        callMcpToolBatch(ptr: I64): I64;
+
+        /**
+         * Collect completed MCP tool calls, waiting up to waitMs for one (negative waits until one completes)
+         * @param ptr Pointer to JSON string containing {waitMs}
+         * @returns Pointer to JSON string containing {success, completed: [{handle, result, error, results}], error}
+         */
+        mcp_poll(ptr: I64): I64;
+
+        /**
+         * Perform an HTTP request allowed by the fetch allowlist
+         * @param ptr Pointer to JSON string containing {url, method, headers, body, encoding}
+         * @returns Pointer to JSON string containing {success, status, statusText, url, headers, body, encoding, error}
+         */
+        http_fetch(ptr: I64): I64;
+
+        /**
+         * Block until the next timer is due, bounded by the execution deadline
+         * @param ptr Pointer to JSON string containing {ms}
+         * @returns Pointer to JSON string containing {success, error}
+         */
+        timer_sleep(ptr: I64): I64;
+
+        /**
+         * Write console output of user code, forwarded to the MCP client if enabled
+         * @param ptr Pointer to JSON string containing {method, message}
+         * @returns Pointer to JSON string containing {success, error}
+         */
+        console_log(ptr: I64): I64;
+
         /**
          * Read a file from the sandbox filesystem
-         * @param ptr Pointer to JSON string containing {path}
+         * @param ptr Pointer to JSON string containing {path, encoding?, offset?, length?}
          * @returns Pointer to JSON string containing {success, data, error}
          */
         workspace_readFile(ptr: I64): I64;
 
         /**
          * Write a file to the sandbox filesystem
-         * @param ptr Pointer to JSON string containing {path, content}
+         * @param ptr Pointer to JSON string containing {path, content, encoding?}
          * @returns Pointer to JSON string containing {success, error}
          */
         workspace_writeFile(ptr: I64): I64;
 
+        /**
+         * Append to a file in the sandbox filesystem
+         * @param ptr Pointer to JSON string containing {path, content, encoding?}
+         * @returns Pointer to JSON string containing {success, error}
+         */
+        workspace_appendFile(ptr: I64): I64;
+
         /**
          * List files in a sandbox filesystem directory
          * @param ptr Pointer to JSON string containing {path}
@@ -46,6 +88,111 @@ declare module "extism:host" {
          * @returns Pointer to JSON string containing {success, error}
          */
         workspace_deleteFile(ptr: I64): I64;
+
+        /**
+         * Get information about a file or directory
+         * @param ptr Pointer to JSON string containing {path}
+         * @returns Pointer to JSON string containing {success, stat: {name, size, mtimeMs, isDirectory}, error}
+         */
+        workspace_stat(ptr: I64): I64;
+
+        /**
+         * Create a directory (and missing parents) in the sandbox filesystem
+         * @param ptr Pointer to JSON string containing {path}
+         * @returns Pointer to JSON string containing {success, error}
+         */
+        workspace_mkdir(ptr: I64): I64;
+
+        /**
+         * Rename or move a file in the sandbox filesystem
+         * @param ptr Pointer to JSON string containing {path, destination}
+         * @returns Pointer to JSON string containing {success, error}
+         */
+        workspace_rename(ptr: I64): I64;
+
+        /**
+         * Copy a file in the sandbox filesystem
+         * @param ptr Pointer to JSON string containing {path, destination}
+         * @returns Pointer to JSON string containing {success, error}
+         */
+        workspace_copy(ptr: I64): I64;
+
+        /**
+         * Find files matching a glob pattern in the sandbox filesystem and /servers
+         * @param ptr Pointer to JSON string containing {path, pattern, maxDepth, limit}
+         * @returns Pointer to JSON string containing {success, matches, truncated, error}
+         */
+        workspace_glob(ptr: I64): I64;
+
+        /**
+         * Find lines matching a regular expression in the sandbox filesystem and /servers
+         * @param ptr Pointer to JSON string containing {path, pattern, query, ignoreCase, maxDepth, limit}
+         * @returns Pointer to JSON string containing {success, matches, truncated, error}
+         */
+        workspace_grep(ptr: I64): I64;
+
+        /**
+         * List previous versions of a file, newest first
+         * @param ptr Pointer to JSON string containing {path}
+         * @returns Pointer to JSON string containing {success, versions, error}
+         */
+        workspace_listVersions(ptr: I64): I64;
+
+        /**
+         * Restore a previous version of a file
+         * @param ptr Pointer to JSON string containing {path, version}
+         * @returns Pointer to JSON string containing {success, error}
+         */
+        workspace_restore(ptr: I64): I64;
+
+        /**
+         * Acquire an advisory lock on a path, waiting if another execution holds it
+         * @param ptr Pointer to JSON string containing {path, timeoutMs?}
+         * @returns Pointer to JSON string containing {success, error}
+         */
+        workspace_lock(ptr: I64): I64;
+
+        /**
+         * Release an advisory lock on a path
+         * @param ptr Pointer to JSON string containing {path}
+         * @returns Pointer to JSON string containing {success, error}
+         */
+        workspace_unlock(ptr: I64): I64;
+
+        /**
+         * Read a value from the workspace key-value store
+         * @param ptr Pointer to JSON string containing {key}
+         * @returns Pointer to JSON string containing {success, found, value, error}
+         */
+        kv_get(ptr: I64): I64;
+
+        /**
+         * Write a value to the workspace key-value store
+         * @param ptr Pointer to JSON string containing {key, value, ttlMs?}
+         * @returns Pointer to JSON string containing {success, error}
+         */
+        kv_set(ptr: I64): I64;
+
+        /**
+         * Delete a key from the workspace key-value store
+         * @param ptr Pointer to JSON string containing {key}
+         * @returns Pointer to JSON string containing {success, found, error}
+         */
+        kv_delete(ptr: I64): I64;
+
+        /**
+         * List entries in the workspace key-value store by key prefix
+         * @param ptr Pointer to JSON string containing {prefix, limit?}
+         * @returns Pointer to JSON string containing {success, entries, error}
+         */
+        kv_list(ptr: I64): I64;
+
+        /**
+         * Atomically add to a numeric value in the workspace key-value store
+         * @param ptr Pointer to JSON string containing {key, delta?, ttlMs?}
+         * @returns Pointer to JSON string containing {success, value, error}
+         */
+        kv_increment(ptr: I64): I64;
     }
 }
 
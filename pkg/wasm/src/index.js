@@ -7,93 +7,485 @@
 
 async function executeCode() {
     try {
-        const {callMcpTool, workspace_readFile, workspace_writeFile, workspace_listFiles, workspace_deleteFile} = Host.getFunctions();
+        const {
+            callMcpTool,
+            callMcpToolBatch,
+            http_fetch,
+            workspace_readFile,
+            workspace_writeFile,
+            workspace_appendFile,
+            workspace_listFiles,
+            workspace_deleteFile,
+            workspace_stat,
+            workspace_mkdir,
+            workspace_rename,
+            workspace_copy,
+            workspace_glob,
+            workspace_grep,
+            workspace_listVersions,
+            workspace_restore,
+            workspace_lock,
+            workspace_unlock,
+            kv_get,
+            kv_set,
+            kv_delete,
+            kv_list,
+            kv_increment,
+            timer_sleep,
+            mcp_poll,
+            console_log
+        } = Host.getFunctions();
         // TODO: Make sure callMcpTool is not accessible
 
+        // MCP tool calls submitted to the host and not yet completed, by handle.
+        // The host runs them concurrently; the event loop collects their results.
+        const pendingCalls = new Map();
+
         /**
          * Call an MCP tool on a downstream server
          * @param {string} serverName - Name of the MCP server
          * @param {string} toolName - Name of the tool to call
          * @param {object} args - Arguments to pass to the tool
-         * @returns {any} The result from the MCP tool
+         * @param {{cache?: boolean}} [options] - Call options; cache: false bypasses the result cache
+         * @returns {Promise<any>} The result from the MCP tool
          */
-        function callTool(serverName, toolName, args) {
+        function callTool(serverName, toolName, args, options) {
             const msg = {
                 serverName,
                 toolName,
-                args: args || {}
+                args: args || {},
+                cache: (options || {}).cache
             };
 
-            // Call host function
+            // Submit the call to the host, which returns a handle
             const mem = Memory.fromString(JSON.stringify(msg));
             const offset = callMcpTool(mem.offset);
             const response = Memory.find(offset).readJsonObject();
 
-            // Check if the call was successful
+            // Check if the call was submitted
             if (response.error) {
-                throw new Error(response.error);
+                return Promise.reject(new Error(response.error));
+            }
+
+            return new Promise((resolve, reject) => {
+                pendingCalls.set(response.handle, { resolve, reject });
+            });
+        }
+
+        /**
+         * Call many MCP tools in a single host call. Calls run concurrently, and the
+         * returned promise resolves once all have completed, with results in call order.
+         * @param {Array<{server: string, tool: string, args?: object, cache?: boolean}>} calls - Calls to make
+         * @param {{concurrency?: number, dedupe?: boolean}} [options] - Calls running at once, and whether identical calls run once
+         * @returns {Promise<Array<{ok: true, value: any} | {ok: false, error: string}>>} Per-call results
+         */
+        function batch(calls, options) {
+            const { concurrency, dedupe } = options || {};
+            const msg = {
+                calls: calls.map(({ server, tool, args, cache }) => ({ serverName: server, toolName: tool, args: args || {}, cache })),
+                concurrency,
+                dedupe
+            };
+
+            // Submit the batch to the host, which returns a single handle
+            const mem = Memory.fromString(JSON.stringify(msg));
+            const offset = callMcpToolBatch(mem.offset);
+            const response = Memory.find(offset).readJsonObject();
+
+            if (response.error) {
+                return Promise.reject(new Error(response.error));
             }
 
-            // Try to parse as JSON, fallback to raw string
+            return new Promise((resolve, reject) => {
+                pendingCalls.set(response.handle, { resolve, reject, batch: true });
+            });
+        }
+
+        /**
+         * Parse a tool result as JSON, falling back to the raw string
+         * @param {string} result - Tool result
+         */
+        function parseResult(result) {
             try {
-                return JSON.parse(response.result);
+                return JSON.parse(result);
             } catch {
-                return response.result;
+                return result;
+            }
+        }
+
+        /**
+         * Resolve the promises of completed MCP tool calls and batches
+         * @param {Array<{handle: number, result: string, error: string, results?: Array}>} completed - Completed calls
+         */
+        function settleCalls(completed) {
+            for (const { handle, result, error, results } of completed) {
+                const call = pendingCalls.get(handle);
+                if (!call) {
+                    continue;
+                }
+                pendingCalls.delete(handle);
+
+                if (call.batch) {
+                    call.resolve((results || []).map((r) => r.error
+                        ? { ok: false, error: r.error }
+                        : { ok: true, value: parseResult(r.result) }));
+                } else if (error) {
+                    call.reject(new Error(error));
+                } else {
+                    call.resolve(parseResult(result));
+                }
+            }
+        }
+
+        /**
+         * Send a JSON request to a filesystem host function
+         * @param {Function} hostFn - Host function to call
+         * @param {object} msg - Request message
+         * @returns {object} The successful response
+         */
+        function fsRequest(hostFn, msg) {
+            const mem = Memory.fromString(JSON.stringify(msg));
+            const offset = hostFn(mem.offset);
+            const response = Memory.find(offset).readJsonObject();
+
+            if (!response.success) {
+                throw new Error(response.error);
             }
+            return response;
+        }
+
+        /**
+         * Convert string or binary content to a request payload
+         * @param {string|Uint8Array|ArrayBuffer} content - Content to send
+         * @returns {{content: string, encoding: string}}
+         */
+        function encodeContent(content) {
+            if (typeof content === 'string') {
+                return { content, encoding: 'utf8' };
+            }
+            if (content instanceof ArrayBuffer) {
+                content = new Uint8Array(content);
+            }
+            if (content instanceof Uint8Array) {
+                return { content: base64Encode(content), encoding: 'base64' };
+            }
+            throw new TypeError('content must be a string, Uint8Array or ArrayBuffer');
+        }
+
+        /**
+         * Perform an HTTP request through the host (subset of the standard fetch API).
+         * Hosts, methods and size limits are enforced by the fetch allowlist in runbyte.json.
+         * @param {string|{url: string}} input - Absolute http(s) URL
+         * @param {{method?: string, headers?: object, body?: string|Uint8Array|ArrayBuffer}} init - Request options
+         * @returns {Promise<FetchResponse>}
+         */
+        async function fetch(input, init) {
+            const options = init || {};
+            const url = typeof input === 'string' ? input : String((input && input.url) || input);
+
+            const headers = {};
+            if (options.headers) {
+                const entries = typeof options.headers.entries === 'function'
+                    ? Array.from(options.headers.entries())
+                    : Array.isArray(options.headers) ? options.headers : Object.entries(options.headers);
+                for (const [key, value] of entries) {
+                    headers[key] = String(value);
+                }
+            }
+
+            const body = options.body == null ? {} : encodeContent(options.body);
+            const response = fsRequest(http_fetch, {
+                url,
+                method: (options.method || 'GET').toUpperCase(),
+                headers,
+                body: body.content,
+                encoding: body.encoding
+            });
+            return new FetchResponse(response);
         }
 
         // Workspace filesystem API
         const workspace = {
-            async readFile(path) {
-                const msg = { path };
-                const mem = Memory.fromString(JSON.stringify(msg));
-                const offset = workspace_readFile(mem.offset);
-                const response = Memory.find(offset).readJsonObject();
-                
-                if (!response.success) {
-                    throw new Error(response.error);
-                }
-                return response.data;
+            async readFile(path, options) {
+                const { offset, length } = options || {};
+                return fsRequest(workspace_readFile, { path, offset, length }).data || '';
+            },
+
+            async readBytes(path, options) {
+                const { offset, length } = options || {};
+                const response = fsRequest(workspace_readFile, { path, offset, length, encoding: 'base64' });
+                return base64Decode(response.data || '');
             },
-            
+
             async writeFile(path, content) {
-                const msg = { path, content };
-                const mem = Memory.fromString(JSON.stringify(msg));
-                const offset = workspace_writeFile(mem.offset);
-                const response = Memory.find(offset).readJsonObject();
-                
-                if (!response.success) {
-                    throw new Error(response.error);
-                }
+                fsRequest(workspace_writeFile, { path, ...encodeContent(content) });
+            },
+
+            async writeBytes(path, data) {
+                fsRequest(workspace_writeFile, { path, ...encodeContent(data) });
+            },
+
+            async appendFile(path, content) {
+                fsRequest(workspace_appendFile, { path, ...encodeContent(content) });
             },
-            
+
             async listFiles(path) {
-                const msg = { path };
-                const mem = Memory.fromString(JSON.stringify(msg));
-                const offset = workspace_listFiles(mem.offset);
-                const response = Memory.find(offset).readJsonObject();
-                
-                if (!response.success) {
-                    throw new Error(response.error);
-                }
-                return response.files;
+                return fsRequest(workspace_listFiles, { path }).files || [];
             },
-            
+
             async deleteFile(path) {
-                const msg = { path };
-                const mem = Memory.fromString(JSON.stringify(msg));
-                const offset = workspace_deleteFile(mem.offset);
-                const response = Memory.find(offset).readJsonObject();
-                
-                if (!response.success) {
-                    throw new Error(response.error);
+                fsRequest(workspace_deleteFile, { path });
+            },
+
+            async stat(path) {
+                const { stat } = fsRequest(workspace_stat, { path });
+                return {
+                    name: stat.name,
+                    size: stat.size,
+                    mtime: new Date(stat.mtimeMs),
+                    mtimeMs: stat.mtimeMs,
+                    isDirectory: stat.isDirectory,
+                    isFile: !stat.isDirectory
+                };
+            },
+
+            async mkdir(path) {
+                fsRequest(workspace_mkdir, { path });
+            },
+
+            async rename(from, to) {
+                fsRequest(workspace_rename, { path: from, destination: to });
+            },
+
+            async copy(from, to) {
+                fsRequest(workspace_copy, { path: from, destination: to });
+            },
+
+            async glob(pattern, options) {
+                const { path, maxDepth, limit } = options || {};
+                const matches = fsRequest(workspace_glob, { path, pattern, maxDepth, limit }).matches || [];
+                return matches.map(({ path, size, mtimeMs }) => ({
+                    path,
+                    size,
+                    mtime: new Date(mtimeMs),
+                    mtimeMs
+                }));
+            },
+
+            async grep(query, options) {
+                const { path, include, ignoreCase, maxDepth, limit } = options || {};
+                const matches = fsRequest(workspace_grep, {
+                    path,
+                    pattern: include,
+                    query: query instanceof RegExp ? query.source : query,
+                    ignoreCase: ignoreCase || (query instanceof RegExp && query.flags.includes('i')),
+                    maxDepth,
+                    limit
+                }).matches || [];
+                return matches.map(({ path, line, text, size }) => ({ path, line, text, size }));
+            },
+
+            async listVersions(path) {
+                const versions = fsRequest(workspace_listVersions, { path }).versions || [];
+                return versions.map(({ id, size, savedAtMs }) => ({
+                    id,
+                    size,
+                    savedAt: new Date(savedAtMs)
+                }));
+            },
+
+            async restore(path, version) {
+                fsRequest(workspace_restore, { path, version });
+            },
+
+            async withLock(path, fn, options) {
+                const { timeoutMs } = options || {};
+                fsRequest(workspace_lock, { path, timeoutMs });
+                try {
+                    return await fn();
+                } finally {
+                    fsRequest(workspace_unlock, { path });
                 }
             }
         };
-        
+
+        // Workspace key-value store API (uses the same {success, error} protocol)
+        const kv = {
+            async get(key) {
+                const response = fsRequest(kv_get, { key });
+                return response.found ? response.value : undefined;
+            },
+
+            async set(key, value, options) {
+                if (value === undefined) {
+                    throw new TypeError('value must be JSON-serializable');
+                }
+                const { ttlMs } = options || {};
+                fsRequest(kv_set, { key, value, ttlMs });
+            },
+
+            async delete(key) {
+                return !!fsRequest(kv_delete, { key }).found;
+            },
+
+            async list(prefix, options) {
+                const { limit } = options || {};
+                const entries = fsRequest(kv_list, { prefix: prefix || '', limit }).entries || [];
+                return entries.map(({ key, value, expiresAt }) => ({
+                    key,
+                    value,
+                    expiresAt: expiresAt ? new Date(expiresAt) : undefined
+                }));
+            },
+
+            async increment(key, delta, options) {
+                const { ttlMs } = options || {};
+                return fsRequest(kv_increment, { key, delta, ttlMs }).value;
+            }
+        };
+
+        // Timers. Callbacks run from the event loop, which blocks on the host
+        // until the next timer is due or a tool call completes instead of spinning.
+        const timers = new Map();
+        let nextTimerId = 1;
+
+        function addTimer(callback, delay, args, repeat) {
+            if (typeof callback !== 'function') {
+                throw new TypeError('timer callback must be a function');
+            }
+            const ms = Math.max(0, Number(delay) || 0);
+            const id = nextTimerId++;
+            timers.set(id, { when: Date.now() + ms, callback, args, interval: repeat ? ms : null });
+            return id;
+        }
+
+        function clearTimer(id) {
+            timers.delete(id);
+        }
+
+        /**
+         * Find the timer that fires next (earliest due, then oldest).
+         * Once exec() has settled, intervals alone no longer keep the loop running.
+         * @param {boolean} settled - Whether exec() has settled
+         */
+        function nextTimer(settled) {
+            let next = null;
+            for (const [id, timer] of timers) {
+                if (settled && timer.interval !== null) {
+                    continue;
+                }
+                if (!next || timer.when < next.timer.when) {
+                    next = { id, timer };
+                }
+            }
+            return next;
+        }
+
+        /**
+         * Let queued promise reactions run before the loop blocks on a timer or tool call.
+         * The job queue cannot be inspected, so reactions (an await on a promise or a then
+         * call) grant the turns their resumptions may need. Pumping stops after as many
+         * quiet turns in a row as there is credit; turns leading up to progress (another
+         * reaction or exec() settling) use up credit, while a quiet stretch at the end was
+         * spent waiting and is kept for the next pump.
+         * @param {() => boolean} isSettled - Whether exec() has settled
+         */
+        let reactionsSeen = 0;
+        let reactionCredit = 0;
+        async function runMicrotasks(isSettled) {
+            reactionCredit += promiseReactions - reactionsSeen;
+            reactionsSeen = promiseReactions;
+            let settled = isSettled();
+            let idle = 0;
+            while (idle < Math.max(1, reactionCredit)) {
+                await null;
+                if (promiseReactions === reactionsSeen && isSettled() === settled) {
+                    idle++;
+                    continue;
+                }
+                reactionCredit += promiseReactions - reactionsSeen - idle - 1;
+                reactionsSeen = promiseReactions;
+                settled = isSettled();
+                idle = 0;
+            }
+        }
+
+        /**
+         * Run timers and collect tool call results until exec() has settled and
+         * no timeouts or tool calls are pending
+         * @param {() => boolean} isSettled - Whether exec() has settled
+         */
+        async function runEventLoop(isSettled) {
+            for (;;) {
+                await runMicrotasks(isSettled);
+
+                const next = nextTimer(isSettled());
+                if (pendingCalls.size > 0 && (!next || next.timer.when > Date.now())) {
+                    // Wait for tool calls until the next timer is due
+                    const waitMs = next ? next.timer.when - Date.now() : -1;
+                    settleCalls(fsRequest(mcp_poll, { waitMs }).completed || []);
+                    continue;
+                }
+                if (!next) {
+                    return;
+                }
+
+                const delay = next.timer.when - Date.now();
+                if (delay > 0) {
+                    fsRequest(timer_sleep, { ms: delay });
+                }
+
+                if (next.timer.interval === null) {
+                    timers.delete(next.id);
+                } else {
+                    next.timer.when = Date.now() + next.timer.interval;
+                }
+                next.timer.callback(...next.timer.args);
+            }
+        }
+
+        globalThis.setTimeout = (callback, delay, ...args) => addTimer(callback, delay, args, false);
+        globalThis.setInterval = (callback, delay, ...args) => addTimer(callback, delay, args, true);
+        globalThis.clearTimeout = clearTimer;
+        globalThis.clearInterval = clearTimer;
+        globalThis.sleep = (ms) => new Promise(resolve => addTimer(resolve, ms, [], false));
+
+        /**
+         * Format console arguments: strings as they are, errors with their stack
+         * and other values as JSON
+         * @param {any[]} args - Arguments passed to a console method
+         * @returns {string}
+         */
+        function formatConsoleArgs(args) {
+            return args.map(arg => {
+                if (typeof arg === 'string') {
+                    return arg;
+                }
+                if (arg instanceof Error) {
+                    return arg.stack ? `${arg}\n${arg.stack}` : String(arg);
+                }
+                try {
+                    const json = JSON.stringify(arg);
+                    return json === undefined ? String(arg) : json;
+                } catch (e) {
+                    return String(arg);
+                }
+            }).join(' ');
+        }
+
+        // Route console output to the host, which forwards it to the MCP client if enabled
+        for (const method of ['debug', 'log', 'info', 'warn', 'error']) {
+            console[method] = (...args) => {
+                fsRequest(console_log, { method, message: formatConsoleArgs(args) });
+            };
+        }
+
         // Expose to bundled code
         globalThis.__runbyte_workspace = workspace;
+        globalThis.__runbyte_kv = kv;
         globalThis.__runbyte_callTool = callTool;
+        globalThis.batch = batch;
+        globalThis.fetch = fetch;
 
         // Get user's code from input
         const code = Host.inputString();
@@ -101,7 +493,23 @@ async function executeCode() {
         try {
             // Execute user's code
             // User can call: callMcpTool("github", "list_repos", {})
-            const result = await eval(code);
+            let settled = false;
+            let failed = false;
+            let result;
+            let failure;
+            Promise.resolve(eval(code)).then(
+                (value) => { settled = true; result = value; },
+                (error) => { settled = true; failed = true; failure = error; }
+            );
+
+            // Drain timers and promise reactions before returning
+            await runEventLoop(() => settled);
+            if (!settled) {
+                throw new Error('exec() did not complete: it is waiting on a promise that never settles');
+            }
+            if (failed) {
+                throw failure;
+            }
 
             // Return result as JSON string
             Host.outputString(JSON.stringify({
@@ -126,5 +534,152 @@ async function executeCode() {
     }
 }
 
+/**
+ * Response headers with case-insensitive lookup
+ */
+class FetchHeaders {
+    constructor(headers) {
+        this._headers = {};
+        for (const [key, value] of Object.entries(headers || {})) {
+            this._headers[key.toLowerCase()] = value;
+        }
+    }
+
+    get(name) {
+        const value = this._headers[String(name).toLowerCase()];
+        return value === undefined ? null : value;
+    }
+
+    has(name) {
+        return String(name).toLowerCase() in this._headers;
+    }
+
+    forEach(callback) {
+        for (const [key, value] of this.entries()) {
+            callback(value, key, this);
+        }
+    }
+
+    entries() {
+        return Object.entries(this._headers)[Symbol.iterator]();
+    }
+
+    keys() {
+        return Object.keys(this._headers)[Symbol.iterator]();
+    }
+
+    [Symbol.iterator]() {
+        return this.entries();
+    }
+}
+
+/**
+ * Response returned by fetch(). The body is fully buffered by the host.
+ */
+class FetchResponse {
+    constructor(response) {
+        this.status = response.status;
+        this.statusText = response.statusText || '';
+        this.ok = response.status >= 200 && response.status < 300;
+        this.url = response.url;
+        this.headers = new FetchHeaders(response.headers);
+        this._body = response.body || '';
+        this._encoding = response.encoding;
+    }
+
+    async text() {
+        if (this._encoding === 'base64') {
+            return new TextDecoder().decode(base64Decode(this._body));
+        }
+        return this._body;
+    }
+
+    async json() {
+        return JSON.parse(await this.text());
+    }
+
+    async bytes() {
+        if (this._encoding === 'base64') {
+            return base64Decode(this._body);
+        }
+        return new TextEncoder().encode(this._body);
+    }
+
+    async arrayBuffer() {
+        const bytes = await this.bytes();
+        return bytes.buffer.slice(bytes.byteOffset, bytes.byteOffset + bytes.byteLength);
+    }
+}
+
+// Number of promise reactions so far, which tells the event loop whether promise
+// chains are still making progress. Awaiting a native promise reads its constructor
+// and then() registers a reaction, so both count.
+let promiseReactions = 0;
+
+const promiseThen = Promise.prototype.then;
+Promise.prototype.then = function then(onFulfilled, onRejected) {
+    promiseReactions++;
+    return promiseThen.call(this, onFulfilled, onRejected);
+};
+Object.defineProperty(Promise.prototype, 'constructor', {
+    get() {
+        promiseReactions++;
+        return Promise;
+    },
+    configurable: true
+});
+
+const BASE64_ALPHABET = 'ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/';
+
+/**
+ * Encode bytes as standard base64
+ * @param {Uint8Array} bytes - Bytes to encode
+ * @returns {string}
+ */
+function base64Encode(bytes) {
+    const parts = [];
+    for (let i = 0; i < bytes.length; i += 3) {
+        const b0 = bytes[i];
+        const b1 = i + 1 < bytes.length ? bytes[i + 1] : 0;
+        const b2 = i + 2 < bytes.length ? bytes[i + 2] : 0;
+        const n = (b0 << 16) | (b1 << 8) | b2;
+
+        parts.push(
+            BASE64_ALPHABET[(n >> 18) & 63] +
+            BASE64_ALPHABET[(n >> 12) & 63] +
+            (i + 1 < bytes.length ? BASE64_ALPHABET[(n >> 6) & 63] : '=') +
+            (i + 2 < bytes.length ? BASE64_ALPHABET[n & 63] : '=')
+        );
+    }
+    return parts.join('');
+}
+
+/**
+ * Decode standard base64 into bytes
+ * @param {string} str - Base64 string
+ * @returns {Uint8Array}
+ */
+function base64Decode(str) {
+    const clean = str.replace(/=+$/, '');
+    const bytes = new Uint8Array(Math.floor(clean.length * 3 / 4));
+
+    let buffer = 0;
+    let bits = 0;
+    let index = 0;
+    for (let i = 0; i < clean.length; i++) {
+        const value = BASE64_ALPHABET.indexOf(clean[i]);
+        if (value < 0) {
+            throw new Error('invalid base64 data');
+        }
+        buffer = (buffer << 6) | value;
+        bits += 6;
+        if (bits >= 8) {
+            bits -= 8;
+            bytes[index++] = (buffer >> bits) & 0xff;
+        }
+    }
+    return bytes;
+}
+
 module.exports = { executeCode };
 